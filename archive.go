@@ -0,0 +1,144 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+// Archive is an archive opened once, from which any number of independent readers can be created cheaply. The seek
+// table is read when opening and shared by all readers, decoders are pooled, and decoded frames are shared through
+// the frame cache if enabled by `WithFrameCache`.
+//
+// Archive is safe for concurrent use, including `ReadAt`. Readers created from it are not, but every reader has its
+// own position and can be used by its own goroutine.
+type Archive struct {
+	src            io.ReaderAt
+	compressedSize int64 // size of src
+	table          *seektable.Table
+	size           atomic.Int64 // decompressed size, -1 until computed since lazily read tables need all entries
+
+	options  readerOptions
+	decoders *decoderPool
+	cache    *frameCache
+	readers  chan *ReadSeeker // readers reused by ReadAt
+}
+
+// OpenArchive reads the seek table of the archive in src. Size of src is taken from its `Size` or `Stat` method, as
+// in `Concat`. Reader options apply to all readers of the archive.
+func OpenArchive(src io.ReaderAt, opts ...ReaderOption) (*Archive, error) {
+	o, err := applyReaderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := readerAtSize(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get size of archive: %w", err)
+	}
+	table, err := readSeekTable(io.NewSectionReader(src, 0, size), size, o)
+	if err != nil {
+		return nil, err
+	}
+	table.CacheOffsets()
+
+	archive := &Archive{
+		src:            src,
+		compressedSize: size,
+		table:          table,
+		options:        o,
+		decoders:       newDecoderPool(runtime.GOMAXPROCS(0), o),
+		cache:          newFrameCache(o.frameCacheSize),
+		readers:        make(chan *ReadSeeker, runtime.GOMAXPROCS(0)),
+	}
+	archive.size.Store(-1)
+	return archive, nil
+}
+
+// NewCursor returns a reader of the archive positioned at its start. It borrows decoders from the archive only
+// while decoding a frame. Closing it releases its own resources and leaves the archive open.
+func (a *Archive) NewCursor() *ReadSeeker {
+	src := io.NewSectionReader(a.src, 0, a.compressedSize)
+	reader := newReadSeeker(src, a.table, uint64(a.compressedSize)-uint64(a.table.Size()), nil, a.options)
+	reader.archive = a
+	reader.cache = a.cache
+	return reader
+}
+
+// ReadAt reads decompressed data at the offset, as `io.ReaderAt`.
+func (a *Archive) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	size := a.Size()
+	if size < 0 {
+		return 0, errors.Join(errors.New("failed to read seek table"), a.table.Err())
+	}
+	if offset >= size {
+		return 0, io.EOF
+	}
+
+	var reader *ReadSeeker
+	select {
+	case reader = <-a.readers:
+	default:
+		reader = a.NewCursor()
+	}
+	defer func() {
+		select {
+		case a.readers <- reader:
+		default:
+			reader.Close()
+		}
+	}()
+
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(reader, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// NewSectionReader returns a reader of n bytes of decompressed data starting at the offset, with its own position.
+func (a *Archive) NewSectionReader(offset, n int64) *io.SectionReader {
+	return io.NewSectionReader(a, offset, n)
+}
+
+// Size returns the total decompressed size of the archive, or -1 if entries of a lazily read seek table cannot be
+// read. The error is then returned by readers of the archive.
+func (a *Archive) Size() int64 {
+	if size := a.size.Load(); size >= 0 {
+		return size
+	}
+	size := int64(decompressedSize(a.table))
+	if a.table.Err() != nil {
+		return -1
+	}
+	a.size.Store(size)
+	return size
+}
+
+// Table returns the seek table of the archive. It must not be modified.
+func (a *Archive) Table() *seektable.Table {
+	return a.table
+}
+
+// Close releases pooled decoders and readers. Readers created by `NewCursor` must not be used afterwards.
+func (a *Archive) Close() error {
+	for {
+		select {
+		case reader := <-a.readers:
+			reader.Close()
+		default:
+			a.decoders.Close()
+			return nil
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package szstd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+// countingReaderAt counts reads of the underlying archive.
+type countingReaderAt struct {
+	*bytes.Reader
+	mu    sync.Mutex
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	c.mu.Lock()
+	c.reads++
+	c.mu.Unlock()
+	return c.Reader.ReadAt(p, offset)
+}
+
+func TestArchiveCursors(t *testing.T) {
+	data := generateTestData(50*1000+13, 53)
+	archive, err := OpenArchive(bytes.NewReader(compressTestData(t, data, 1000, WithChecksums(true))))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer archive.Close()
+	if archive.Size() != int64(len(data)) || archive.Table().NumEntries() != 51 {
+		t.Fatalf("unexpected size %d and %d entries", archive.Size(), archive.Table().NumEntries())
+	}
+
+	// Cursors keep their own positions
+	first, second := archive.NewCursor(), archive.NewCursor()
+	defer first.Close()
+	defer second.Close()
+	if _, err := second.Seek(30*1000+5, io.SeekStart); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	buffer := make([]byte, 1500)
+	for i := 0; i < 3; i++ {
+		if _, err := io.ReadFull(first, buffer); err != nil || !bytes.Equal(buffer, data[i*1500:(i+1)*1500]) {
+			t.Fatalf("first cursor read wrong data: %v", err)
+		}
+		start := 30*1000 + 5 + i*1500
+		if _, err := io.ReadFull(second, buffer); err != nil || !bytes.Equal(buffer, data[start:start+1500]) {
+			t.Fatalf("second cursor read wrong data: %v", err)
+		}
+	}
+
+	// Cursors and ReadAt used concurrently
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := range 8 {
+		wg.Go(func() {
+			cursor := archive.NewCursor()
+			defer cursor.Close()
+			offset := int64(i * 5000)
+			if _, err := cursor.Seek(offset, io.SeekStart); err != nil {
+				errs <- err
+				return
+			}
+			decompressed, err := io.ReadAll(cursor)
+			if err != nil || !bytes.Equal(decompressed, data[offset:]) {
+				errs <- errors.Join(errors.New("cursor read wrong data"), err)
+			}
+		})
+		wg.Go(func() {
+			buffer := make([]byte, 3000)
+			offset := int64(i*6000 + 7)
+			if _, err := archive.ReadAt(buffer, offset); err != nil || !bytes.Equal(buffer, data[offset:offset+3000]) {
+				errs <- errors.Join(errors.New("ReadAt read wrong data"), err)
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	// ReadAt at the end of data
+	if n, err := archive.ReadAt(buffer, int64(len(data))-100); n != 100 || err != io.EOF {
+		t.Fatalf("expected 100 bytes and EOF, got %d %v", n, err)
+	}
+	if n, err := archive.ReadAt(buffer, int64(len(data))); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF, got %d %v", n, err)
+	}
+
+	// Section views
+	section := archive.NewSectionReader(12345, 20*1000)
+	if err := iotest.TestReader(section, data[12345:12345+20*1000]); err != nil {
+		t.Fatalf("section reader test failed: %v", err)
+	}
+}
+
+func TestArchiveFrameCache(t *testing.T) {
+	data := generateTestData(10*1000, 54)
+	src := &countingReaderAt{Reader: bytes.NewReader(compressTestData(t, data, 1000))}
+	archive, err := OpenArchive(src, WithFrameCache(3000))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer archive.Close()
+
+	buffer := make([]byte, 10)
+	readAt := func(offset int64) int {
+		t.Helper()
+		reads := src.reads
+		if _, err := archive.ReadAt(buffer, offset); err != nil || !bytes.Equal(buffer, data[offset:offset+10]) {
+			t.Fatalf("failed to read at %d: %v", offset, err)
+		}
+		return src.reads - reads
+	}
+	for _, offset := range []int64{100, 1100, 2100} {
+		if reads := readAt(offset); reads == 0 {
+			t.Fatalf("frame at %d was not read", offset)
+		}
+	}
+	// All three frames fit into the cache, also for new cursors
+	for _, offset := range []int64{200, 1200, 2200} {
+		if reads := readAt(offset); reads != 0 {
+			t.Fatalf("cached frame at %d was read %d times", offset, reads)
+		}
+	}
+	cursor := archive.NewCursor()
+	defer cursor.Close()
+	if _, err := cursor.Seek(1500, io.SeekStart); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	reads := src.reads
+	if _, err := io.ReadFull(cursor, buffer); err != nil || !bytes.Equal(buffer, data[1500:1510]) || src.reads != reads {
+		t.Fatalf("cursor did not read cached frame: %v", err)
+	}
+
+	// The least recently used frame is evicted
+	readAt(3100)
+	if reads := readAt(100); reads == 0 {
+		t.Fatalf("evicted frame was not read")
+	}
+	if reads := readAt(1100); reads != 0 { // frame 2 was evicted for frame 0
+		t.Fatalf("cached frame was read %d times", reads)
+	}
+
+	// Cached frames are not modified by readers reusing their buffers
+	decompressed, err := io.ReadAll(archive.NewSectionReader(0, archive.Size()))
+	if err != nil || !bytes.Equal(decompressed, data) {
+		t.Fatalf("failed to read all data: %v", err)
+	}
+	decompressed, err = io.ReadAll(archive.NewSectionReader(0, archive.Size()))
+	if err != nil || !bytes.Equal(decompressed, data) {
+		t.Fatalf("failed to read all data again: %v", err)
+	}
+}
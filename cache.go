@@ -0,0 +1,63 @@
+package szstd
+
+import (
+	"container/list"
+	"sync"
+)
+
+// frameCache keeps decoded frames up to a total size, evicting the least recently used ones. Cached data is shared
+// and must not be modified. Methods of a nil cache do nothing. It is safe for concurrent use.
+type frameCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	frames   map[int]*list.Element // of *cachedFrame
+	lru      list.List             // most recently used first
+}
+
+type cachedFrame struct {
+	index int
+	data  []byte
+}
+
+// newFrameCache returns a cache of at most maxBytes bytes, or nil if maxBytes is zero.
+func newFrameCache(maxBytes int64) *frameCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &frameCache{maxBytes: maxBytes, frames: make(map[int]*list.Element)}
+}
+
+// get returns data of the frame with the given index if it is cached.
+func (c *frameCache) get(index int) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.frames[index]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*cachedFrame).data, true
+}
+
+// put caches data of the frame. The cache takes ownership of data. Frames larger than the cache are not cached.
+func (c *frameCache) put(index int, data []byte) {
+	if c == nil || int64(len(data)) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.frames[index]; ok {
+		return // decoded concurrently by another reader
+	}
+	for c.bytes+int64(len(data)) > c.maxBytes {
+		oldest := c.lru.Remove(c.lru.Back()).(*cachedFrame)
+		delete(c.frames, oldest.index)
+		c.bytes -= int64(len(oldest.data))
+	}
+	c.frames[index] = c.lru.PushFront(&cachedFrame{index: index, data: data})
+	c.bytes += int64(len(data))
+}
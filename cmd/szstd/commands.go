@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+func runCompress(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("compress", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	frameSize := sizeFlag(1024 * 1024)
+	flags.Var(&frameSize, "frame-size", "decompressed size of every frame")
+	level := flags.Int("level", 3, "zstd compression level (1-22)")
+	concurrency := flags.Int("concurrency", 0, "number of frames compressed in parallel, 0 uses all CPUs")
+	checksums := flags.Bool("checksums", false, "store checksums of frames in the seek table")
+	output := flags.String("o", "-", "output file")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if frameSize <= 0 || int64(frameSize) > szstd.MaxFrameSize {
+		return fmt.Errorf("frame size must be between 1 and %d", szstd.MaxFrameSize)
+	}
+
+	in, closeIn, err := openInput(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	writer, err := szstd.NewWriterWithOptions(out, int(frameSize),
+		szstd.WithWriterConcurrency(*concurrency),
+		szstd.WithChecksums(*checksums),
+		szstd.WithEncoderOptions(zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(*level))),
+	)
+	if err != nil {
+		return errors.Join(err, closeOut())
+	}
+	if _, err := io.Copy(writer, in); err != nil {
+		return errors.Join(fmt.Errorf("failed to compress: %w", err), writer.Close(), closeOut())
+	}
+	if err := writer.Close(); err != nil {
+		return errors.Join(fmt.Errorf("failed to compress: %w", err), closeOut())
+	}
+	return closeOut()
+}
+
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "auto", "input format: auto, zstd, gzip or raw")
+	frameSize := sizeFlag(1024 * 1024)
+	flags.Var(&frameSize, "frame-size", "decompressed size of every frame")
+	level := flags.Int("level", 3, "zstd compression level (1-22)")
+	concurrency := flags.Int("concurrency", 0, "number of frames compressed in parallel, 0 uses all CPUs")
+	checksums := flags.Bool("checksums", false, "store checksums of frames in the seek table")
+	output := flags.String("o", "-", "output file")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if frameSize <= 0 || int64(frameSize) > szstd.MaxFrameSize {
+		return fmt.Errorf("frame size must be between 1 and %d", szstd.MaxFrameSize)
+	}
+	opts := []szstd.ConvertOption{szstd.WithWriterOptions(
+		szstd.WithWriterConcurrency(*concurrency),
+		szstd.WithChecksums(*checksums),
+		szstd.WithEncoderOptions(zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(*level))),
+	)}
+	switch *format {
+	case "auto":
+	case "zstd":
+		opts = append(opts, szstd.WithDecompressor(szstd.ZstdDecompressor))
+	case "gzip":
+		opts = append(opts, szstd.WithDecompressor(szstd.GzipDecompressor))
+	case "raw":
+		opts = append(opts, szstd.WithDecompressor(szstd.RawDecompressor))
+	default:
+		return fmt.Errorf("unknown input format %q", *format)
+	}
+
+	in, closeIn, err := openInput(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	if _, err := szstd.Convert(out, in, int(frameSize), opts...); err != nil {
+		return errors.Join(fmt.Errorf("failed to convert: %w", err), closeOut())
+	}
+	return closeOut()
+}
+
+func runTranscode(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("transcode", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	frameSize := sizeFlag(1024 * 1024)
+	flags.Var(&frameSize, "frame-size", "decompressed size of every frame")
+	level := flags.Int("level", 3, "zstd compression level (1-22), frames are not compressed again if not set")
+	concurrency := flags.Int("concurrency", 0, "number of frames processed in parallel, 0 uses all CPUs")
+	checksums := flags.Bool("checksums", false, "store checksums of frames in the seek table")
+	output := flags.String("o", "-", "output file")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if frameSize <= 0 || int64(frameSize) > szstd.MaxFrameSize {
+		return fmt.Errorf("frame size must be between 1 and %d", szstd.MaxFrameSize)
+	}
+
+	archive, closeIn, err := openArchive(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	targetOptions := []szstd.WriterOption{szstd.WithWriterConcurrency(*concurrency), szstd.WithChecksums(*checksums)}
+	if flagSet(flags, "level") {
+		// Without a level, frames are copied without compressing them again
+		targetOptions = append(targetOptions, szstd.WithEncoderOptions(zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(*level))))
+	}
+	stats, err := szstd.Transcode(out, archive, int(frameSize), szstd.WithTargetOptions(targetOptions...))
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to transcode: %w", err), closeOut())
+	}
+	fmt.Fprintf(stderr, "%d frames reused, %d frames recompressed\n", stats.ReusedFrames, stats.ReencodedFrames)
+	return closeOut()
+}
+
+func runConcat(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("concat", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "-", "output file")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "expected at least one input")
+		return errUsage
+	}
+
+	var srcs []io.ReaderAt
+	for _, path := range flags.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		srcs = append(srcs, f)
+	}
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	if err := szstd.Concat(out, srcs...); err != nil {
+		return errors.Join(fmt.Errorf("failed to concatenate: %w", err), closeOut())
+	}
+	return closeOut()
+}
+
+func runDecompress(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("decompress", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "-", "output file")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	reader, closeIn, err := openDecompressed(input, stdin, 0)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, reader); err != nil {
+		return errors.Join(fmt.Errorf("failed to decompress: %w", err), closeOut())
+	}
+	return closeOut()
+}
+
+func runCat(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("cat", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	offset := sizeFlag(0)
+	flags.Var(&offset, "offset", "decompressed offset of the first byte")
+	length := sizeFlag(-1)
+	flags.Var(&length, "length", "number of bytes to print, -1 prints until the end")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if offset < 0 {
+		return errors.New("offset must not be negative")
+	}
+
+	reader, closeIn, err := openDecompressed(input, stdin, int64(offset))
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+
+	if length < 0 {
+		_, err = io.Copy(stdout, reader)
+	} else {
+		_, err = io.CopyN(stdout, reader, int64(length))
+		if err == io.EOF {
+			err = nil // range is clamped to the end of data
+		}
+	}
+	return err
+}
+
+func runSlice(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("slice", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	offset := sizeFlag(0)
+	flags.Var(&offset, "offset", "decompressed offset of the first byte")
+	length := sizeFlag(-1)
+	flags.Var(&length, "length", "number of bytes to extract, -1 extracts until the end")
+	level := flags.Int("level", 3, "zstd compression level (1-22) of the frames at range edges")
+	output := flags.String("o", "-", "output file")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if offset < 0 {
+		return errors.New("offset must not be negative")
+	}
+
+	archive, closeIn, err := openArchive(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	info, err := szstd.Inspect(archive)
+	if err != nil {
+		return err
+	}
+	end := int64(info.DecompressedSize)
+	if length >= 0 && int64(length) < end-int64(offset) {
+		end = int64(offset + length) // otherwise range is clamped to the end of data
+	}
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	err = szstd.Slice(out, archive, min(int64(offset), end), end, szstd.WithTargetOptions(
+		szstd.WithEncoderOptions(zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(*level)))))
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to slice: %w", err), closeOut())
+	}
+	return closeOut()
+}
+
+func runList(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	archive, closeIn, err := openArchive(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	table, err := seektable.ReadTableFromReadSeeker(archive)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "frame\tcompressed offset\tcompressed size\tdecompressed offset\tdecompressed size\tratio\t")
+	var totalCompressed, totalDecompressed uint64
+	for i := 0; i < table.NumEntries(); i++ {
+		offsets := table.OffsetsByIndex(i)
+		entry := table.GetEntry(i)
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%s\t\n", i,
+			offsets.EntryOffsetInCompressed, entry.CompressedSize,
+			offsets.EntryOffsetInDecompressed, entry.DecompressedSize,
+			ratio(uint64(entry.DecompressedSize), uint64(entry.CompressedSize)))
+		totalCompressed += uint64(entry.CompressedSize)
+		totalDecompressed += uint64(entry.DecompressedSize)
+	}
+	fmt.Fprintf(tw, "total\t\t%d\t\t%d\t%s\t\n", totalCompressed, totalDecompressed, ratio(totalDecompressed, totalCompressed))
+	return tw.Flush()
+}
+
+func runVerify(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	concurrency := flags.Int("concurrency", 0, "number of frames decoded in parallel, 0 uses all CPUs")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	archive, closeIn, err := openArchive(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	report, err := szstd.Verify(archive, *concurrency)
+	if err != nil {
+		return err
+	}
+
+	for _, frame := range report.BadFrames {
+		compressed, decompressed := frame.CompressedRange(), frame.DecompressedRange()
+		fmt.Fprintf(stdout, "frame %d: compressed [%d, %d), decompressed [%d, %d): %v\n", frame.EntryIndex,
+			compressed.Start, compressed.End, decompressed.Start, decompressed.End, frame.Err)
+	}
+	if report.TrailingGarbage.Len() > 0 {
+		fmt.Fprintf(stdout, "trailing garbage: compressed [%d, %d)\n", report.TrailingGarbage.Start, report.TrailingGarbage.End)
+	}
+	if !report.OK() {
+		return fmt.Errorf("archive is corrupted: %d of %d frames failed verification", len(report.BadFrames), report.NumFrames)
+	}
+	checksums := "without checksums"
+	if report.ChecksumsChecked {
+		checksums = "checksums verified"
+	}
+	fmt.Fprintf(stdout, "ok: %d frames, %d bytes, %s\n", report.NumFrames, report.DecompressedSize, checksums)
+	return nil
+}
+
+func runRepair(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("repair", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "-", "output file")
+	input, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	archive, closeIn, err := openArchive(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	out, closeOut, err := createOutput(*output, stdout)
+	if err != nil {
+		return err
+	}
+
+	report, err := szstd.Repair(out, archive)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to repair: %w", err), closeOut())
+	}
+	for _, lost := range report.Lost {
+		decompressed := "unknown"
+		if lost.DecompressedKnown {
+			decompressed = fmt.Sprintf("[%d, %d)", lost.Decompressed.Start, lost.Decompressed.End)
+		}
+		fmt.Fprintf(stderr, "lost compressed [%d, %d), decompressed %s: %v\n",
+			lost.Compressed.Start, lost.Compressed.End, decompressed, lost.Err)
+	}
+	source := "seek table"
+	if !report.UsedSeekTable {
+		source = "frame scan"
+	}
+	fmt.Fprintf(stderr, "kept %d frames, %d bytes (located by %s)\n", report.KeptFrames, report.DecompressedSize, source)
+	return closeOut()
+}
+
+// parseFlags parses flags and returns the optional positional input argument.
+func parseFlags(flags *flag.FlagSet, args []string) (string, error) {
+	if err := flags.Parse(args); err != nil {
+		return "", errUsage
+	}
+	switch flags.NArg() {
+	case 0:
+		return "-", nil
+	case 1:
+		return flags.Arg(0), nil
+	default:
+		fmt.Fprintf(flags.Output(), "expected at most one input, got %d\n", flags.NArg())
+		return "", errUsage
+	}
+}
+
+// flagSet tells whether the flag was given on the command line.
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+func openInput(path string, stdin io.Reader) (io.Reader, func() error, error) {
+	if path == "-" {
+		return stdin, func() error { return nil }, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+// openArchive opens input for random access. Non-seekable stdin is read into memory.
+func openArchive(path string, stdin io.Reader) (io.ReadSeeker, func() error, error) {
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+	if f, ok := seekableStdin(stdin); ok {
+		return f, func() error { return nil }, nil
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return bytes.NewReader(data), func() error { return nil }, nil
+}
+
+// openDecompressed opens input for reading decompressed data from the offset. Non-seekable stdin is read
+// sequentially instead of into memory, discarding data before the offset.
+func openDecompressed(path string, stdin io.Reader, offset int64) (io.Reader, func() error, error) {
+	if _, ok := seekableStdin(stdin); path == "-" && !ok {
+		reader, err := szstd.NewReader(stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+		skipped, err := reader.Skip(offset)
+		if err != nil && err != io.EOF {
+			return nil, nil, errors.Join(fmt.Errorf("failed to skip to offset %d: %w", offset, err), reader.Close())
+		}
+		if skipped < offset {
+			// As seeking beyond the end of a seekable input
+			return nil, nil, errors.Join(fmt.Errorf("offset %d is beyond end of data at %d", offset, skipped), reader.Close())
+		}
+		return reader, reader.Close, nil
+	}
+
+	archive, closeIn, err := openArchive(path, stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := szstd.NewReadSeeker(archive)
+	if err != nil {
+		return nil, nil, errors.Join(err, closeIn())
+	}
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to seek to offset %d: %w", offset, err), reader.Close(), closeIn())
+	}
+	return reader, func() error { return errors.Join(reader.Close(), closeIn()) }, nil
+}
+
+// seekableStdin returns stdin if it is a seekable file, such as a redirected regular file.
+func seekableStdin(stdin io.Reader) (*os.File, bool) {
+	f, ok := stdin.(*os.File)
+	if !ok {
+		return nil, false
+	}
+	_, err := f.Seek(0, io.SeekCurrent)
+	return f, err == nil
+}
+
+func createOutput(path string, stdout io.Writer) (io.Writer, func() error, error) {
+	if path == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+func ratio(decompressed, compressed uint64) string {
+	if compressed == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(float64(decompressed)/float64(compressed), 'f', 2, 64)
+}
+
+// sizeFlag is a byte size flag accepting K, M and G suffixes (powers of 1024).
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	multiplier := int64(1)
+	switch strings.ToUpper(value[len(value)-min(1, len(value)):]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		value = value[:len(value)-1]
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	if n > math.MaxInt64/multiplier || n < math.MinInt64/multiplier {
+		return strconv.ErrRange
+	}
+	*s = sizeFlag(n * multiplier)
+	return nil
+}
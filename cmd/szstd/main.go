@@ -0,0 +1,80 @@
+// Command szstd creates and inspects seekable zstd archives.
+//
+// Usage:
+//
+//	szstd compress [-frame-size 1M] [-level 3] [-concurrency 0] [-checksums] [-o output] [input]
+//	szstd convert [-format auto] [-frame-size 1M] [-level 3] [-concurrency 0] [-checksums] [-o output] [input]
+//	szstd transcode [-frame-size 1M] [-level 3] [-concurrency 0] [-checksums] [-o output] input
+//	szstd concat [-o output] input...
+//	szstd decompress [-o output] input
+//	szstd cat -offset N -length N input
+//	szstd slice -offset N -length N [-level 3] [-o output] input
+//	szstd list input
+//	szstd verify [-concurrency 0] input
+//	szstd repair [-o output] input
+//
+// Input and output default to stdin and stdout where possible. Sizes accept K, M and G suffixes.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: szstd <command> [flags] [input]
+
+commands:
+  compress    compress input into a seekable archive
+  convert     convert a zstd, gzip or raw stream into a seekable archive
+  transcode   rewrite a seekable archive with other frame size or level
+  concat      join seekable archives without recompression
+  decompress  decompress a seekable archive
+  cat         print a decompressed byte range
+  slice       extract a decompressed byte range into a new archive
+  list        print seek table entries
+  verify      decode every frame of an archive
+  repair      copy intact frames of a damaged archive into a new one
+
+run "szstd <command> -h" for command flags
+`
+
+var errUsage = errors.New("invalid usage")
+
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+var commands = map[string]command{
+	"compress":   runCompress,
+	"convert":    runConvert,
+	"transcode":  runTranscode,
+	"concat":     runConcat,
+	"decompress": runDecompress,
+	"cat":        runCat,
+	"slice":      runSlice,
+	"list":       runList,
+	"verify":     runVerify,
+	"repair":     runRepair,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "szstd: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+	return cmd(args[1:], stdin, stdout, stderr)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	archive := filepath.Join(dir, "input.txt.zst")
+	data := []byte(strings.Repeat("seekable zstd command line tool\n", 10_000))
+	if err := os.WriteFile(input, data, 0o644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	runStdin := func(stdin []byte, args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := run(args, bytes.NewReader(stdin), &stdout, &stderr); err != nil {
+			t.Fatalf("szstd %s failed: %v\n%s", strings.Join(args, " "), err, stderr.String())
+		}
+		return stdout.String()
+	}
+	runOK := func(args ...string) string {
+		t.Helper()
+		return runStdin(nil, args...)
+	}
+
+	runOK("compress", "-frame-size", "4K", "-level", "5", "-concurrency", "3", "-checksums", "-o", archive, input)
+
+	if output := runOK("decompress", archive); output != string(data) {
+		t.Fatalf("decompressed data does not match input")
+	}
+	if output := runOK("cat", "-offset", "5000", "-length", "1234", archive); output != string(data[5000:6234]) {
+		t.Fatalf("cat output does not match input range")
+	}
+	if output := runOK("cat", "-offset", "1K", archive); output != string(data[1024:]) {
+		t.Fatalf("cat output until the end does not match input")
+	}
+
+	// Non-seekable stdin is read sequentially
+	compressed, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	if output := runStdin(compressed, "decompress"); output != string(data) {
+		t.Fatalf("decompressed stdin does not match input")
+	}
+	if output := runStdin(compressed, "cat", "-offset", "5000", "-length", "1234"); output != string(data[5000:6234]) {
+		t.Fatalf("cat output of stdin does not match input range")
+	}
+	beyond := strconv.Itoa(len(data) + 1)
+	if err := run([]string{"cat", "-offset", beyond, archive}, nil, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("cat succeeded with offset beyond the end of the file")
+	}
+	if err := run([]string{"cat", "-offset", beyond}, bytes.NewReader(compressed), &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("cat succeeded with offset beyond the end of stdin")
+	}
+	if output := runStdin(compressed, "cat", "-offset", strconv.Itoa(len(data))); output != "" {
+		t.Fatalf("unexpected cat output at the end of stdin: %q", output)
+	}
+
+	list := runOK("list", archive)
+	if lines := strings.Count(list, "\n"); lines != 2+(len(data)+4095)/4096 {
+		t.Fatalf("unexpected number of list lines %d:\n%s", lines, list)
+	}
+	if output := runOK("verify", archive); !strings.HasPrefix(output, "ok:") {
+		t.Fatalf("unexpected verify output: %s", output)
+	}
+
+	converted := filepath.Join(dir, "converted.zst")
+	runOK("convert", "-frame-size", "8K", "-o", converted, archive)
+	if output := runOK("decompress", converted); output != string(data) {
+		t.Fatalf("converted data does not match input")
+	}
+
+	transcoded := filepath.Join(dir, "transcoded.zst")
+	runOK("transcode", "-frame-size", "16K", "-level", "1", "-o", transcoded, archive)
+	if output := runOK("decompress", transcoded); output != string(data) {
+		t.Fatalf("transcoded data does not match input")
+	}
+
+	// Without a level, frames of the same size are copied
+	copied := filepath.Join(dir, "copied.zst")
+	runOK("transcode", "-frame-size", "4K", "-checksums", "-o", copied, archive)
+	if output, err := os.ReadFile(copied); err != nil || !bytes.Equal(output, compressed) {
+		t.Fatalf("transcoded archive differs from the source: %v", err)
+	}
+
+	joined := filepath.Join(dir, "joined.zst")
+	runOK("concat", "-o", joined, archive, transcoded)
+	if output := runOK("decompress", joined); output != string(data)+string(data) {
+		t.Fatalf("concatenated data does not match input")
+	}
+
+	sliced := filepath.Join(dir, "sliced.zst")
+	runOK("slice", "-offset", "5000", "-length", "20K", "-o", sliced, archive)
+	if output := runOK("decompress", sliced); output != string(data[5000:5000+20*1024]) {
+		t.Fatalf("sliced data does not match input range")
+	}
+	runOK("slice", "-offset", "5000", "-length", "8589934591G", "-o", sliced, archive)
+	if output := runOK("decompress", sliced); output != string(data[5000:]) {
+		t.Fatalf("sliced data until the end does not match input")
+	}
+
+	// Corrupt a frame in the middle of the archive
+	for i := len(compressed) / 2; i < len(compressed)/2+64; i++ {
+		compressed[i] ^= 0xFF
+	}
+	if err := os.WriteFile(archive, compressed, 0o644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	var stdout bytes.Buffer
+	if err := run([]string{"verify", archive}, nil, &stdout, &bytes.Buffer{}); err == nil {
+		t.Fatalf("verify of corrupted archive succeeded:\n%s", stdout.String())
+	}
+
+	repaired := filepath.Join(dir, "repaired.zst")
+	runOK("repair", "-o", repaired, archive)
+	if output := runOK("verify", repaired); !strings.HasPrefix(output, "ok:") {
+		t.Fatalf("unexpected verify output for repaired archive: %s", output)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stderr bytes.Buffer
+	if err := run([]string{"unknown"}, nil, &bytes.Buffer{}, &stderr); err != errUsage {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
+
+func TestSizeFlag(t *testing.T) {
+	for value, expected := range map[string]int64{"0": 0, "12": 12, "3K": 3 << 10, "5m": 5 << 20, "-1": -1, "8G": 8 << 30} {
+		var s sizeFlag
+		if err := s.Set(value); err != nil || int64(s) != expected {
+			t.Fatalf("size %q: expected %d, got %d (%v)", value, expected, s, err)
+		}
+	}
+	for _, value := range []string{"", "K", "1.5M", "8589934592G", "-8589934593G"} {
+		var s sizeFlag
+		if err := s.Set(value); err == nil {
+			t.Fatalf("size %q accepted as %d", value, s)
+		}
+	}
+}
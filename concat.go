@@ -0,0 +1,96 @@
+package szstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+// Concat writes the frames of all srcs archives into dst in order, followed by a seek table combining their entries.
+// Nothing is decompressed or compressed again. The combined seek table has checksums only if all sources have them.
+// Trailing skippable frames of the sources, such as the tar index, are not copied since their offsets are no longer
+// valid in the combined archive. Head seek tables of the sources (see `WithHeadSeekTable`) are dropped for the
+// same reason.
+// Size of every source is taken from its `Size() int64` or `Stat() (fs.FileInfo, error)` method, as implemented by
+// `bytes.Reader`, `io.SectionReader` and `os.File`.
+func Concat(dst io.Writer, srcs ...io.ReaderAt) error {
+	sections := make([]*io.SectionReader, len(srcs))
+	tables := make([]*seektable.Table, len(srcs))
+	checksums := true
+	numEntries := 0
+	for i, src := range srcs {
+		size, err := readerAtSize(src)
+		if err != nil {
+			return fmt.Errorf("failed to get size of archive %d: %w", i, err)
+		}
+		sections[i] = io.NewSectionReader(src, 0, size)
+		tables[i], err = seektable.ReadTableFromReadSeeker(sections[i])
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to read seek table of archive %d", i), err)
+		}
+		if framesEnd(tables[i]) > uint64(size)-uint64(tables[i].Size()) {
+			return fmt.Errorf("seek table of archive %d describes more data than available", i)
+		}
+		checksums = checksums && tables[i].HasChecksums()
+		numEntries += tables[i].NumEntries()
+	}
+	if numEntries > seektable.MaxEntries(checksums) {
+		return fmt.Errorf("%w: archives have %d frames together, at most %d", ErrTooManyFrames, numEntries, seektable.MaxEntries(checksums))
+	}
+
+	combined := seektable.NewTable(checksums)
+	for i, table := range tables {
+		// Frames described by the seek table always start at the beginning of the archive
+		first, start, err := skipHeadSeekTable(sections[i], table)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to read first frame of archive %d", i), err)
+		}
+		if _, err := io.Copy(dst, io.NewSectionReader(sections[i], start, int64(framesEnd(table))-start)); err != nil {
+			return errors.Join(fmt.Errorf("failed to copy frames of archive %d", i), err)
+		}
+		for j := first; j < table.NumEntries(); j++ {
+			combined.AppendEntry(table.GetEntry(j))
+		}
+	}
+
+	if _, err := seektable.WriteTableToWriter(combined, dst); err != nil {
+		return errors.Join(errors.New("failed to write seek table"), err)
+	}
+	return nil
+}
+
+// skipHeadSeekTable returns the index and the offset of the first frame after the head seek table, if the archive
+// has one.
+func skipHeadSeekTable(r io.ReaderAt, table *seektable.Table) (int, int64, error) {
+	if table.NumEntries() == 0 || table.GetEntry(0).DecompressedSize != 0 {
+		return 0, 0, nil
+	}
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return 0, 0, err
+	}
+	if binary.LittleEndian.Uint32(magic[:]) != seekTableMagicNumber {
+		return 0, 0, nil
+	}
+	return 1, int64(table.GetEntry(0).CompressedSize), nil
+}
+
+// readerAtSize returns the size of r from its `Size` or `Stat` method.
+func readerAtSize(r io.ReaderAt) (int64, error) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), nil
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	default:
+		return 0, errors.New("size of io.ReaderAt is unknown, it must implement Size or Stat")
+	}
+}
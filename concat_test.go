@@ -0,0 +1,91 @@
+package szstd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	const frameSize = 4 * 1024
+	parts := [][]byte{
+		generateTestData(5*frameSize+100, 35),
+		nil,
+		generateTestData(frameSize/2, 36),
+		generateTestData(3*frameSize, 37),
+	}
+
+	concat := func(t *testing.T, srcs ...io.ReaderAt) []byte {
+		t.Helper()
+		archive := bytes.NewBuffer(nil)
+		if err := Concat(archive, srcs...); err != nil {
+			t.Fatalf("failed to concatenate archives: %v", err)
+		}
+		reader, err := NewReadSeeker(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open concatenated archive: %v", err)
+		}
+		defer reader.Close()
+		if reader.NumFrames() != 6+0+1+3 {
+			t.Fatalf("expected 10 frames, got %d", reader.NumFrames())
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read concatenated archive: %v", err)
+		}
+		if !bytes.Equal(decompressed, slices.Concat(parts...)) {
+			t.Fatalf("concatenated data does not match")
+		}
+		return archive.Bytes()
+	}
+
+	t.Run("checksums", func(t *testing.T) {
+		var srcs []io.ReaderAt
+		for _, part := range parts {
+			srcs = append(srcs, bytes.NewReader(compressTestData(t, part, frameSize, WithChecksums(true))))
+		}
+		report, err := Verify(bytes.NewReader(concat(t, srcs...)), 2)
+		if err != nil || !report.OK() || !report.ChecksumsChecked {
+			t.Fatalf("unexpected verify report %+v: %v", report, err)
+		}
+	})
+
+	t.Run("mixed checksums", func(t *testing.T) {
+		var srcs []io.ReaderAt
+		for i, part := range parts {
+			srcs = append(srcs, bytes.NewReader(compressTestData(t, part, frameSize, WithChecksums(i%2 == 0))))
+		}
+		report, err := Verify(bytes.NewReader(concat(t, srcs...)), 2)
+		if err != nil || !report.OK() || report.ChecksumsChecked {
+			t.Fatalf("unexpected verify report %+v: %v", report, err)
+		}
+	})
+
+	t.Run("files", func(t *testing.T) {
+		var srcs []io.ReaderAt
+		for i, part := range parts {
+			path := filepath.Join(t.TempDir(), "part.zst")
+			if err := os.WriteFile(path, compressTestData(t, part, frameSize), 0o644); err != nil {
+				t.Fatalf("failed to write archive %d: %v", i, err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("failed to open archive %d: %v", i, err)
+			}
+			defer f.Close()
+			srcs = append(srcs, f)
+		}
+		concat(t, srcs...)
+	})
+
+	t.Run("unknown size", func(t *testing.T) {
+		var src struct{ io.ReaderAt }
+		src.ReaderAt = bytes.NewReader(compressTestData(t, parts[0], frameSize))
+		if err := Concat(io.Discard, src); err == nil {
+			t.Fatalf("expected error for source without size")
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package szstd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var metadataKind = [4]byte{'M', 'E', 'T', 'A'}
+
+// Metadata describes the original stream converted by `Convert`. It is stored as JSON in a skippable frame
+// right before the seek table.
+type Metadata struct {
+	Format  string    `json:"format"` // "zstd", "gzip" or "raw"
+	Name    string    `json:"name,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+	ModTime time.Time `json:"modTime,omitzero"`
+	Extra   []byte    `json:"extra,omitempty"`
+}
+
+// Decompressor returns decompressed data of src. Information found in the stream header is stored into metadata.
+type Decompressor func(src io.Reader, metadata *Metadata) (io.ReadCloser, error)
+
+// ZstdDecompressor decompresses zstd streams with any number of frames, including regular `.zst` files.
+func ZstdDecompressor(src io.Reader, metadata *Metadata) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(src)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to create zstd decoder"), err)
+	}
+	metadata.Format = "zstd"
+	return decoder.IOReadCloser(), nil
+}
+
+// GzipDecompressor decompresses gzip streams. Name, comment, modification time and extra field of the first
+// gzip header are stored into metadata.
+func GzipDecompressor(src io.Reader, metadata *Metadata) (io.ReadCloser, error) {
+	decoder, err := gzip.NewReader(src)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read gzip header"), err)
+	}
+	metadata.Format = "gzip"
+	metadata.Name = decoder.Name
+	metadata.Comment = decoder.Comment
+	metadata.ModTime = decoder.ModTime
+	metadata.Extra = decoder.Extra
+	return decoder, nil
+}
+
+// RawDecompressor passes src through unchanged.
+func RawDecompressor(src io.Reader, metadata *Metadata) (io.ReadCloser, error) {
+	metadata.Format = "raw"
+	return io.NopCloser(src), nil
+}
+
+// DetectDecompressor chooses decompressor by the magic number at the start of the stream.
+// Data that is neither zstd nor gzip is treated as raw.
+func DetectDecompressor(header []byte) Decompressor {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1F, 0x8B}):
+		return GzipDecompressor
+	case len(header) >= 4 && binary.LittleEndian.Uint32(header) == zstdMagicNumber:
+		return ZstdDecompressor
+	case len(header) >= 4 && binary.LittleEndian.Uint32(header)&skippableMagicMask == skippableMagicBase:
+		return ZstdDecompressor
+	default:
+		return RawDecompressor
+	}
+}
+
+// ConvertStats counts data processed by `Convert`.
+type ConvertStats struct {
+	InputBytes        int64 // consumed from the source stream
+	DecompressedBytes int64 // written into the seekable archive
+}
+
+// ConvertOption is an option for `Convert`.
+type ConvertOption func(*convertOptions) error
+
+type convertOptions struct {
+	decompressor  Decompressor
+	writerOptions []WriterOption
+	metadata      *Metadata
+	progress      func(ConvertStats)
+}
+
+// WithDecompressor sets decompressor of the source stream instead of detecting it by magic number.
+func WithDecompressor(decompressor Decompressor) ConvertOption {
+	return func(o *convertOptions) error {
+		o.decompressor = decompressor
+		return nil
+	}
+}
+
+// WithWriterOptions passes options to the writer of the seekable archive.
+func WithWriterOptions(opts ...WriterOption) ConvertOption {
+	return func(o *convertOptions) error {
+		o.writerOptions = append(o.writerOptions, opts...)
+		return nil
+	}
+}
+
+// WithMetadata stores the given metadata instead of the one found in the source stream header.
+func WithMetadata(metadata Metadata) ConvertOption {
+	return func(o *convertOptions) error {
+		o.metadata = &metadata
+		return nil
+	}
+}
+
+// WithProgress calls fn after every chunk of data written into the seekable archive.
+func WithProgress(fn func(ConvertStats)) ConvertOption {
+	return func(o *convertOptions) error {
+		o.progress = fn
+		return nil
+	}
+}
+
+// Convert decompresses src and writes it into dst as a seekable archive with the given frame size, in one pass.
+// The source format is detected by its magic number unless `WithDecompressor` is used. Metadata of the source
+// stream is stored in the archive and can be read back with `ReadMetadata`. Use `WithWriterOptions` to compress
+// frames concurrently.
+func Convert(dst io.Writer, src io.Reader, frameSize int, opts ...ConvertOption) (ConvertStats, error) {
+	var o convertOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return ConvertStats{}, errors.Join(errors.New("invalid convert option"), err)
+		}
+	}
+
+	input := &countingReader{r: src}
+	buffered := bufio.NewReader(input)
+	decompressor := o.decompressor
+	if decompressor == nil {
+		header, err := buffered.Peek(4)
+		if err != nil && err != io.EOF {
+			return ConvertStats{}, errors.Join(errors.New("failed to read source header"), err)
+		}
+		decompressor = DetectDecompressor(header)
+	}
+
+	var metadata Metadata
+	decompressed, err := decompressor(buffered, &metadata)
+	if err != nil {
+		return ConvertStats{}, err
+	}
+	defer decompressed.Close()
+	if o.metadata != nil {
+		metadata = *o.metadata
+	}
+
+	writer, err := NewWriterWithOptions(dst, frameSize, o.writerOptions...)
+	if err != nil {
+		return ConvertStats{}, err
+	}
+
+	var stats ConvertStats
+	buffer := make([]byte, 64*1024) // the writer splits data into frames
+	for {
+		n, readErr := io.ReadFull(decompressed, buffer)
+		if n > 0 {
+			if _, err := writer.Write(buffer[:n]); err != nil {
+				return stats, errors.Join(err, writer.Close())
+			}
+			stats.InputBytes = input.n - int64(buffered.Buffered())
+			stats.DecompressedBytes += int64(n)
+			if o.progress != nil {
+				o.progress(stats)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return stats, errors.Join(errors.New("failed to decompress source"), readErr, writer.Close())
+		}
+	}
+
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		return stats, errors.Join(errors.New("failed to encode metadata"), err, writer.Close())
+	}
+	writer.trailers = append(writer.trailers, appendTrailerFrame(nil, metadataKind, payload))
+	if err := writer.Close(); err != nil {
+		return stats, err
+	}
+	stats.InputBytes = input.n - int64(buffered.Buffered())
+	return stats, nil
+}
+
+// ReadMetadata returns metadata of the source stream stored by `Convert`.
+// Returns `ErrTrailerNotFound` if the archive was not created by `Convert`.
+func ReadMetadata(r io.ReadSeeker) (*Metadata, error) {
+	payload, err := readTrailer(r, metadataKind)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &Metadata{}
+	if err := json.Unmarshal(payload, metadata); err != nil {
+		return nil, errors.Join(errors.New("failed to decode metadata"), err)
+	}
+	return metadata, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,168 @@
+package szstd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestConvert(t *testing.T) {
+	const frameSize = 16 * 1024
+	data := generateTestData(10*frameSize+123, 21)
+	modTime := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+
+	gzipped := bytes.NewBuffer(nil)
+	gzipWriter := gzip.NewWriter(gzipped)
+	gzipWriter.Name = "data.txt"
+	gzipWriter.Comment = "partner export"
+	gzipWriter.ModTime = modTime
+	if _, err := gzipWriter.Write(data); err != nil {
+		t.Fatalf("failed to write gzip data: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("failed to create zstd encoder: %v", err)
+	}
+	zstdStream := encoder.EncodeAll(data[:len(data)/2], nil)
+	zstdStream = encoder.EncodeAll(data[len(data)/2:], zstdStream) // second frame
+	encoder.Close()
+
+	convert := func(t *testing.T, src []byte, opts ...ConvertOption) ([]byte, ConvertStats) {
+		t.Helper()
+		archive := bytes.NewBuffer(nil)
+		stats, err := Convert(archive, bytes.NewReader(src), frameSize, opts...)
+		if err != nil {
+			t.Fatalf("failed to convert: %v", err)
+		}
+		reader, err := NewReadSeeker(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open converted archive: %v", err)
+		}
+		defer reader.Close()
+		if reader.NumFrames() != 11 {
+			t.Fatalf("expected 11 frames, got %d", reader.NumFrames())
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read converted archive: %v", err)
+		}
+		if !bytes.Equal(decompressed, data) {
+			t.Fatalf("converted data does not match")
+		}
+		if stats.DecompressedBytes != int64(len(data)) || stats.InputBytes != int64(len(src)) {
+			t.Fatalf("unexpected stats %+v, input has %d bytes", stats, len(src))
+		}
+		return archive.Bytes(), stats
+	}
+	readMetadata := func(t *testing.T, archive []byte) *Metadata {
+		t.Helper()
+		metadata, err := ReadMetadata(bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("failed to read metadata: %v", err)
+		}
+		return metadata
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		var progress []ConvertStats
+		archive, _ := convert(t, gzipped.Bytes(),
+			WithWriterOptions(WithWriterConcurrency(3), WithChecksums(true)),
+			WithProgress(func(stats ConvertStats) { progress = append(progress, stats) }),
+		)
+		metadata := readMetadata(t, archive)
+		if metadata.Format != "gzip" || metadata.Name != "data.txt" || metadata.Comment != "partner export" || !metadata.ModTime.Equal(modTime) {
+			t.Fatalf("unexpected metadata %+v", metadata)
+		}
+		if len(progress) < 2 || progress[len(progress)-1].DecompressedBytes != int64(len(data)) {
+			t.Fatalf("unexpected progress reports %+v", progress)
+		}
+		for i := 1; i < len(progress); i++ {
+			if progress[i].DecompressedBytes <= progress[i-1].DecompressedBytes || progress[i].InputBytes < progress[i-1].InputBytes {
+				t.Fatalf("progress is not monotonic: %+v", progress)
+			}
+		}
+	})
+
+	t.Run("zstd", func(t *testing.T) {
+		archive, _ := convert(t, zstdStream)
+		if metadata := readMetadata(t, archive); metadata.Format != "zstd" {
+			t.Fatalf("unexpected metadata %+v", metadata)
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		archive, _ := convert(t, data, WithMetadata(Metadata{Format: "raw", Name: "data.txt"}))
+		if metadata := readMetadata(t, archive); metadata.Format != "raw" || metadata.Name != "data.txt" {
+			t.Fatalf("unexpected metadata %+v", metadata)
+		}
+	})
+
+	t.Run("explicit decompressor", func(t *testing.T) {
+		// Compressed input stored as is
+		archive := bytes.NewBuffer(nil)
+		if _, err := Convert(archive, bytes.NewReader(gzipped.Bytes()), frameSize, WithDecompressor(RawDecompressor)); err != nil {
+			t.Fatalf("failed to convert: %v", err)
+		}
+		reader, err := NewReadSeeker(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open converted archive: %v", err)
+		}
+		defer reader.Close()
+		if reader.Size() != int64(gzipped.Len()) {
+			t.Fatalf("expected %d bytes, got %d", gzipped.Len(), reader.Size())
+		}
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		archive := compressTestData(t, data, frameSize)
+		if _, err := ReadMetadata(bytes.NewReader(archive)); !errors.Is(err, ErrTrailerNotFound) {
+			t.Fatalf("expected ErrTrailerNotFound, got %v", err)
+		}
+	})
+}
+
+func TestConvertStreamingFramesMemory(t *testing.T) {
+	const frameSize = 256 * 1024 * 1024
+	data := generateTestData(8*1024*1024, 22)
+	gzipped := bytes.NewBuffer(nil)
+	gzipWriter, err := gzip.NewWriterLevel(gzipped, gzip.BestSpeed)
+	if err != nil {
+		t.Fatalf("failed to create gzip writer: %v", err)
+	}
+	if _, err := gzipWriter.Write(data); err != nil {
+		t.Fatalf("failed to write gzip data: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	archive := bytes.NewBuffer(make([]byte, 0, len(data)))
+	if _, err := Convert(archive, bytes.NewReader(gzipped.Bytes()), frameSize, WithWriterOptions(WithStreamingFrames(true))); err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > frameSize/4 {
+		t.Fatalf("convert allocated %d bytes for frames of %d bytes", allocated, frameSize)
+	}
+	reader, err := NewReadSeeker(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if decompressed, err := io.ReadAll(reader); err != nil || !bytes.Equal(decompressed, data) {
+		t.Fatalf("failed to read converted data: %v", err)
+	}
+}
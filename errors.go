@@ -0,0 +1,34 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrFrameSizeMismatch = errors.New("decompressed frame size does not match seek table")
+var ErrChecksumMismatch = errors.New("frame checksum does not match seek table")
+var ErrFrameOutOfBounds = errors.New("frame extends beyond the data before seek table")
+var ErrInvalidFrame = errors.New("invalid zstd frame")
+var ErrFrameTooLarge = errors.New("frame size does not fit into seek table entry")
+var ErrTooManyFrames = errors.New("number of frames exceeds the seek table limit")
+var ErrFrameSizeLimit = errors.New("frame size exceeds the reader limit")
+var ErrDecompressedSizeLimit = errors.New("decompressed size exceeds the reader limit")
+var ErrCompressionRatioLimit = errors.New("compression ratio exceeds the reader limit")
+var ErrSeekTableMismatch = errors.New("frames do not match seek table")
+
+// FrameError reports a frame that cannot be read or decoded. Use `errors.As` to get it from returned errors.
+// Embedded `Frame` describes compressed and decompressed ranges of the lost data.
+type FrameError struct {
+	Frame
+	Err error
+}
+
+func (e *FrameError) Error() string {
+	compressed, decompressed := e.CompressedRange(), e.DecompressedRange()
+	return fmt.Sprintf("frame %d (compressed [%d, %d), decompressed [%d, %d)): %v", e.EntryIndex,
+		compressed.Start, compressed.End, decompressed.Start, decompressed.End, e.Err)
+}
+
+func (e *FrameError) Unwrap() error {
+	return e.Err
+}
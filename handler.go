@@ -0,0 +1,97 @@
+package szstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/opengs/szstd/internal/xxh64"
+	"github.com/opengs/szstd/seektable"
+)
+
+// Handler serves decompressed content of an archive over HTTP with `http.ServeContent`, so `Range` (including
+// multiple ranges), `If-Range`, conditional requests and `HEAD` are supported. Every request reads through
+// `Archive.ReadAt` and decompresses only the frames covering the requested ranges.
+//
+// The archive is opened once and shared between requests, so its seek table, decoders and readers are reused.
+// `Content-Length` is the total decompressed size. The ETag is a hash of the size and modification time of the
+// archive and of its seek table, or only its footer if the table is read lazily. It is strong if the modification
+// time is known, or the seek table with checksums of frames is hashed. Otherwise it is weak, since equal frame sizes
+// do not guarantee equal content; `If-Range` needs a strong ETag.
+type Handler struct {
+	archive *Archive
+
+	name    string
+	modTime time.Time
+	etag    string
+}
+
+// NewHandler creates a handler serving the archive read from src. Size of src is taken from its `Size` or `Stat`
+// method, as in `Concat`. Name is used to detect `Content-Type` by extension, and modTime for `Last-Modified`
+// unless it is zero. See `http.ServeContent`.
+func NewHandler(src io.ReaderAt, name string, modTime time.Time, opts ...ReaderOption) (*Handler, error) {
+	archive, err := OpenArchive(src, opts...)
+	if err != nil {
+		return nil, err
+	}
+	etag, err := archiveETag(archive.table, archive.compressedSize, modTime, archive.options.lazyTable)
+	if err != nil {
+		return nil, errors.Join(err, archive.Close())
+	}
+	return &Handler{archive: archive, name: name, modTime: modTime, etag: etag}, nil
+}
+
+// archiveETag hashes the archive size, modification time and seek table into an entity tag. Only the number of
+// entries of a lazily read table is hashed, since hashing its entries would read all of them.
+func archiveETag(table *seektable.Table, size int64, modTime time.Time, lazy bool) (string, error) {
+	digest := xxh64.New()
+	var header [25]byte
+	binary.LittleEndian.PutUint64(header[0:], uint64(size))
+	binary.LittleEndian.PutUint64(header[8:], uint64(modTime.Unix()))
+	binary.LittleEndian.PutUint32(header[16:], uint32(modTime.Nanosecond()))
+	digest.Write(header[:20])
+	if lazy {
+		binary.LittleEndian.PutUint32(header[20:], uint32(table.NumEntries()))
+		if table.HasChecksums() {
+			header[24] = 1
+		}
+		digest.Write(header[20:])
+	} else if _, err := seektable.WriteTableToWriter(table, digest); err != nil {
+		return "", errors.Join(errors.New("failed to hash seek table"), err)
+	}
+
+	etag := fmt.Sprintf(`"%016x"`, digest.Sum64())
+	if modTime.IsZero() && (lazy || !table.HasChecksums()) {
+		etag = "W/" + etag
+	}
+	return etag, nil
+}
+
+// ETag returns the entity tag sent with every response.
+func (h *Handler) ETag() string {
+	return h.etag
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	size := h.archive.Size()
+	if size < 0 {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", h.etag)
+	http.ServeContent(w, req, h.name, h.modTime, io.NewSectionReader(h.archive, 0, size))
+}
+
+// Close releases pooled decoders and readers of the archive. Requests served after Close create new ones.
+func (h *Handler) Close() error {
+	return h.archive.Close()
+}
@@ -0,0 +1,174 @@
+package szstd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	const frameSize = 4 * 1024
+	data := generateTestData(10*frameSize+300, 38)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler, err := NewHandler(bytes.NewReader(compressTestData(t, data, frameSize, WithChecksums(true))), "data.txt", modTime)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	defer handler.Close()
+
+	serve := func(method string, header http.Header) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, "/data.txt", nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Result()
+	}
+	body := func(resp *http.Response) []byte {
+		t.Helper()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return data
+	}
+
+	t.Run("full content", func(t *testing.T) {
+		resp := serve(http.MethodGet, nil)
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(body(resp), data) {
+			t.Fatalf("unexpected response %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Content-Length") != strconv.Itoa(len(data)) || resp.Header.Get("ETag") != handler.ETag() {
+			t.Fatalf("unexpected headers %v", resp.Header)
+		}
+		if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+			t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		resp := serve(http.MethodHead, nil)
+		if resp.StatusCode != http.StatusOK || len(body(resp)) != 0 || resp.Header.Get("Content-Length") != strconv.Itoa(len(data)) {
+			t.Fatalf("unexpected response %d %v", resp.StatusCode, resp.Header)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		resp := serve(http.MethodGet, http.Header{"Range": {"bytes=4000-9000"}})
+		if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body(resp), data[4000:9001]) {
+			t.Fatalf("unexpected response %d", resp.StatusCode)
+		}
+		resp = serve(http.MethodGet, http.Header{"Range": {"bytes=-100"}})
+		if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body(resp), data[len(data)-100:]) {
+			t.Fatalf("unexpected response for suffix range %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("multiple ranges", func(t *testing.T) {
+		resp := serve(http.MethodGet, http.Header{"Range": {"bytes=10-20,30000-30100"}})
+		if resp.StatusCode != http.StatusPartialContent {
+			t.Fatalf("unexpected status %d", resp.StatusCode)
+		}
+		_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("failed to parse content type: %v", err)
+		}
+		parts := multipart.NewReader(resp.Body, params["boundary"])
+		for _, expected := range [][]byte{data[10:21], data[30000:30101]} {
+			part, err := parts.NextPart()
+			if err != nil {
+				t.Fatalf("failed to read part: %v", err)
+			}
+			if got, _ := io.ReadAll(part); !bytes.Equal(got, expected) {
+				t.Fatalf("part does not match")
+			}
+		}
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		resp := serve(http.MethodGet, http.Header{"If-None-Match": {handler.ETag()}})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Fatalf("expected not modified, got %d", resp.StatusCode)
+		}
+		resp = serve(http.MethodGet, http.Header{"Range": {"bytes=0-9"}, "If-Range": {handler.ETag()}})
+		if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body(resp), data[:10]) {
+			t.Fatalf("expected partial content for matching If-Range, got %d", resp.StatusCode)
+		}
+		resp = serve(http.MethodGet, http.Header{"Range": {"bytes=0-9"}, "If-Range": {`"outdated"`}})
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(body(resp), data) {
+			t.Fatalf("expected full content for outdated If-Range, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("reused readers", func(t *testing.T) {
+		// Requests read through the archive, whose readers and decoders are kept for following requests
+		for i := range 10 {
+			resp := serve(http.MethodGet, http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", i*1000, i*1000+5000)}})
+			if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body(resp), data[i*1000:i*1000+5001]) {
+				t.Fatalf("unexpected response %d", resp.StatusCode)
+			}
+		}
+		if pooled := len(handler.archive.readers); pooled != 1 {
+			t.Fatalf("expected 1 pooled reader, got %d", pooled)
+		}
+	})
+
+	t.Run("method", func(t *testing.T) {
+		if resp := serve(http.MethodPost, nil); resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatalf("expected method not allowed, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		compressed := compressTestData(t, data, frameSize)
+		etag := func(modTime time.Time, opts ...ReaderOption) string {
+			t.Helper()
+			h, err := NewHandler(bytes.NewReader(compressed), "data.txt", modTime, opts...)
+			if err != nil {
+				t.Fatalf("failed to create handler: %v", err)
+			}
+			defer h.Close()
+			return h.ETag()
+		}
+
+		// Without checksums only the modification time makes the ETag strong
+		if tag := etag(modTime); strings.HasPrefix(tag, "W/") {
+			t.Fatalf("expected strong ETag with modification time, got %s", tag)
+		}
+		if tag := etag(time.Time{}); !strings.HasPrefix(tag, "W/") {
+			t.Fatalf("expected weak ETag without checksums and modification time, got %s", tag)
+		}
+		if etag(modTime) == etag(modTime.Add(time.Second)) {
+			t.Fatalf("ETag does not depend on modification time")
+		}
+
+		// A lazily read table is not read to compute the ETag
+		src := &countingReaderAt{Reader: bytes.NewReader(compressed)}
+		h, err := NewHandler(src, "data.txt", modTime, WithLazySeekTable(2, 1))
+		if err != nil {
+			t.Fatalf("failed to create handler: %v", err)
+		}
+		defer h.Close()
+		if src.reads > 1 || strings.HasPrefix(h.ETag(), "W/") {
+			t.Fatalf("unexpected %d reads and ETag %s", src.reads, h.ETag())
+		}
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/data.txt", nil)
+		req.Header.Set("Range", "bytes=5-9")
+		req.Header.Set("If-Range", h.ETag())
+		h.ServeHTTP(resp, req)
+		if resp.Code != http.StatusPartialContent || !bytes.Equal(resp.Body.Bytes(), data[5:10]) {
+			t.Fatalf("expected partial content for matching If-Range, got %d", resp.Code)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package szstd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+var testWords = []string{"seek", "table", "frame", "zstd", "offset", "archive", "window", "entry", "block", "stream"}
+
+// generateTestData returns deterministic, moderately compressible data of the given size.
+func generateTestData(size int, seed uint64) []byte {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9E3779B97F4A7C15))
+	data := make([]byte, 0, size+16)
+	for len(data) < size {
+		if rng.IntN(8) == 0 {
+			data = append(data, byte(rng.Uint32()))
+			continue
+		}
+		data = append(data, testWords[rng.IntN(len(testWords))]...)
+		data = append(data, ' ')
+	}
+	return data[:size]
+}
+
+// compressTestData compresses data into a seekable archive with the given frame size.
+func compressTestData(t testing.TB, data []byte, frameSize int, opts ...WriterOption) []byte {
+	t.Helper()
+
+	compressed := bytes.NewBuffer(nil)
+	writer, err := NewWriterWithOptions(compressed, frameSize, opts...)
+	if err != nil {
+		t.Fatalf("failed to create szstd writer: %v", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("failed to write data to szstd writer: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close szstd writer: %v", err)
+	}
+	return compressed.Bytes()
+}
+
+// compressionBomb returns a frame of the given number of RLE blocks, each decompressing 4 bytes to 128 KiB. The frame
+// header does not declare the decompressed size.
+func compressionBomb(blocks int) []byte {
+	frame := []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00, 0x68} // window of 8 MiB
+	for i := range blocks {
+		header := uint32(maxBlockSize<<3 | 1<<1) // RLE block
+		if i == blocks-1 {
+			header |= 1 // last block
+		}
+		frame = append(binary.LittleEndian.AppendUint32(frame, header)[:len(frame)+3], 'x')
+	}
+	return frame
+}
+
+// compressionBombArchive returns an archive of a single `compressionBomb` frame whose seek table entry declares
+// 10 decompressed bytes.
+func compressionBombArchive(t testing.TB, blocks int) []byte {
+	t.Helper()
+
+	archive := bytes.NewBuffer(compressionBomb(blocks))
+	table := seektable.NewTable(false)
+	table.AppendEntry(seektable.TableEntry{CompressedSize: uint32(archive.Len()), DecompressedSize: 10})
+	if _, err := seektable.WriteTableToWriter(table, archive); err != nil {
+		t.Fatalf("failed to write seek table: %v", err)
+	}
+	return archive.Bytes()
+}
@@ -0,0 +1,142 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// Frame describes position and sizes of a single frame of the archive.
+type Frame struct {
+	seektable.TableOffset
+	seektable.TableEntry
+}
+
+// Range is a half-open byte range [Start, End).
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+func (r Range) Len() uint64 {
+	return r.End - r.Start
+}
+
+// CompressedRange returns the position of the frame in the archive.
+func (f Frame) CompressedRange() Range {
+	return Range{Start: f.EntryOffsetInCompressed, End: f.EntryOffsetInCompressed + uint64(f.CompressedSize)}
+}
+
+// DecompressedRange returns the position of the frame data in the decompressed stream.
+func (f Frame) DecompressedRange() Range {
+	return Range{Start: f.EntryOffsetInDecompressed, End: f.EntryOffsetInDecompressed + uint64(f.DecompressedSize)}
+}
+
+// FrameHeader holds information from the zstd frame header. It is read without decompressing the frame.
+type FrameHeader struct {
+	WindowSize     uint64 // equals to ContentSize for single segment frames
+	DictionaryID   uint32 // 0 if the frame does not use a dictionary
+	ContentSize    uint64 // only valid if HasContentSize is set
+	HasContentSize bool
+	HasChecksum    bool // frame ends with a checksum of its content
+	Skippable      bool // frame carries no data, other fields are not set
+}
+
+type FrameInfo struct {
+	Frame
+	Header FrameHeader
+}
+
+// SizeStats summarizes sizes of all frames.
+type SizeStats struct {
+	Min uint32
+	Max uint32
+	Avg float64
+}
+
+// ArchiveInfo describes a seekable archive. See `Inspect`.
+type ArchiveInfo struct {
+	NumFrames        int
+	CompressedSize   uint64 // size of all frames without seek table and trailing skippable frames
+	DecompressedSize uint64
+	SeekTableSize    int
+	HasChecksums     bool // seek table stores checksums of decompressed frames
+
+	CompressedFrameSize   SizeStats
+	DecompressedFrameSize SizeStats
+
+	Frames []FrameInfo
+}
+
+// Inspect reads the seek table and the header of every frame without decompressing any data.
+func Inspect(r io.ReadSeeker) (*ArchiveInfo, error) {
+	table, err := seektable.ReadTableFromReadSeeker(r)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read seek table"), err)
+	}
+
+	info := &ArchiveInfo{
+		NumFrames:     table.NumEntries(),
+		SeekTableSize: table.Size(),
+		HasChecksums:  table.HasChecksums(),
+		Frames:        make([]FrameInfo, table.NumEntries()),
+	}
+
+	var headerBuffer [zstd.HeaderMaxSize]byte
+	for i := range info.Frames {
+		frame := Frame{TableOffset: table.OffsetsByIndex(i), TableEntry: table.GetEntry(i)}
+
+		if _, err := r.Seek(int64(frame.EntryOffsetInCompressed), io.SeekStart); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to seek to frame %d", i), err)
+		}
+		headerData := headerBuffer[:min(len(headerBuffer), int(frame.CompressedSize))]
+		if _, err := io.ReadFull(r, headerData); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to read header of frame %d", i), err)
+		}
+		header, err := decodeFrameHeader(headerData)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to decode header of frame %d", i), err)
+		}
+		info.Frames[i] = FrameInfo{Frame: frame, Header: header}
+
+		info.CompressedSize += uint64(frame.CompressedSize)
+		info.DecompressedSize += uint64(frame.DecompressedSize)
+		if i == 0 {
+			info.CompressedFrameSize = SizeStats{Min: frame.CompressedSize, Max: frame.CompressedSize}
+			info.DecompressedFrameSize = SizeStats{Min: frame.DecompressedSize, Max: frame.DecompressedSize}
+		}
+		info.CompressedFrameSize.Min = min(info.CompressedFrameSize.Min, frame.CompressedSize)
+		info.CompressedFrameSize.Max = max(info.CompressedFrameSize.Max, frame.CompressedSize)
+		info.DecompressedFrameSize.Min = min(info.DecompressedFrameSize.Min, frame.DecompressedSize)
+		info.DecompressedFrameSize.Max = max(info.DecompressedFrameSize.Max, frame.DecompressedSize)
+	}
+
+	if info.NumFrames > 0 {
+		info.CompressedFrameSize.Avg = float64(info.CompressedSize) / float64(info.NumFrames)
+		info.DecompressedFrameSize.Avg = float64(info.DecompressedSize) / float64(info.NumFrames)
+	}
+
+	return info, nil
+}
+
+func decodeFrameHeader(data []byte) (FrameHeader, error) {
+	var h zstd.Header
+	if err := h.Decode(data); err != nil {
+		return FrameHeader{}, err
+	}
+	header := FrameHeader{
+		Skippable:      h.Skippable,
+		WindowSize:     h.WindowSize,
+		DictionaryID:   h.DictionaryID,
+		ContentSize:    h.FrameContentSize,
+		HasContentSize: h.HasFCS,
+		HasChecksum:    h.HasCheckSum,
+	}
+	if h.SingleSegment {
+		header.WindowSize = h.FrameContentSize
+	}
+	return header, nil
+}
@@ -0,0 +1,94 @@
+package szstd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+func TestInspect(t *testing.T) {
+	const frameSize = 32 * 1024
+	data := generateTestData(10*frameSize+1000, 6)
+	compressed := compressTestData(t, data, frameSize)
+
+	info, err := Inspect(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("failed to inspect archive: %v", err)
+	}
+
+	if info.NumFrames != 11 {
+		t.Fatalf("expected 11 frames, got %d", info.NumFrames)
+	}
+	if info.DecompressedSize != uint64(len(data)) {
+		t.Fatalf("expected decompressed size %d, got %d", len(data), info.DecompressedSize)
+	}
+	if info.CompressedSize+uint64(info.SeekTableSize) != uint64(len(compressed)) {
+		t.Fatalf("compressed size %d and seek table size %d do not add up to archive size %d", info.CompressedSize, info.SeekTableSize, len(compressed))
+	}
+	if info.HasChecksums {
+		t.Fatalf("archive must not have seek table checksums")
+	}
+	if info.DecompressedFrameSize.Min != 1000 || info.DecompressedFrameSize.Max != frameSize {
+		t.Fatalf("unexpected decompressed frame size stats: %+v", info.DecompressedFrameSize)
+	}
+	if avg := float64(len(data)) / 11; info.DecompressedFrameSize.Avg != avg {
+		t.Fatalf("expected average decompressed frame size %f, got %f", avg, info.DecompressedFrameSize.Avg)
+	}
+	if info.CompressedFrameSize.Min == 0 || info.CompressedFrameSize.Min > info.CompressedFrameSize.Max {
+		t.Fatalf("unexpected compressed frame size stats: %+v", info.CompressedFrameSize)
+	}
+
+	var compressedOffset uint64
+	for i, frame := range info.Frames {
+		if frame.EntryIndex != i || frame.EntryOffsetInCompressed != compressedOffset {
+			t.Fatalf("frame %d has unexpected position: %+v", i, frame.Frame)
+		}
+		compressedOffset += uint64(frame.CompressedSize)
+
+		if !frame.Header.HasContentSize || frame.Header.ContentSize != uint64(frame.DecompressedSize) {
+			t.Fatalf("frame %d header content size %d does not match seek table size %d", i, frame.Header.ContentSize, frame.DecompressedSize)
+		}
+		if frame.Header.WindowSize == 0 || frame.Header.DictionaryID != 0 || frame.Header.Skippable {
+			t.Fatalf("frame %d has unexpected header: %+v", i, frame.Header)
+		}
+		if !frame.Header.HasChecksum {
+			t.Fatalf("frame %d must have content checksum", i)
+		}
+	}
+}
+
+func TestInspectSeekTableChecksums(t *testing.T) {
+	// Archive with seek table checksums, as written by the reference implementation
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("failed to create zstd encoder: %v", err)
+	}
+	defer encoder.Close()
+
+	archive := bytes.NewBuffer(nil)
+	table := seektable.NewTable(true)
+	for i := range 3 {
+		data := generateTestData(1000+i, uint64(i))
+		frame := encoder.EncodeAll(data, nil)
+		archive.Write(frame)
+		table.AppendEntry(seektable.TableEntry{CompressedSize: uint32(len(frame)), DecompressedSize: uint32(len(data)), Checksum: uint32(i + 1)})
+	}
+	if _, err := seektable.WriteTableToWriter(table, archive); err != nil {
+		t.Fatalf("failed to write seek table: %v", err)
+	}
+
+	info, err := Inspect(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to inspect archive: %v", err)
+	}
+	if !info.HasChecksums || info.NumFrames != 3 {
+		t.Fatalf("unexpected archive info: %+v", info)
+	}
+	for i, frame := range info.Frames {
+		if frame.Checksum != uint32(i+1) {
+			t.Fatalf("frame %d has checksum %d, expected %d", i, frame.Checksum, i+1)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package xxh64 implements the 64-bit xxHash algorithm with seed 0, as used by zstd frame and seek table checksums.
+package xxh64
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Variables rather than constants, so that arithmetic wraps around instead of overflowing at compile time.
+var (
+	prime1 uint64 = 11400714785074694791
+	prime2 uint64 = 14029467366897019727
+	prime3 uint64 = 1609587929392839161
+	prime4 uint64 = 9650029242287828579
+	prime5 uint64 = 2870177450012600261
+)
+
+// Digest computes XXH64 incrementally.
+type Digest struct {
+	v1, v2, v3, v4 uint64
+	total          uint64
+	mem            [32]byte
+	n              int // number of bytes buffered in mem
+}
+
+// New creates a digest with seed 0.
+func New() *Digest {
+	d := &Digest{}
+	d.Reset()
+	return d
+}
+
+func (d *Digest) Reset() {
+	d.v1 = prime1 + prime2
+	d.v2 = prime2
+	d.v3 = 0
+	d.v4 = -prime1
+	d.total = 0
+	d.n = 0
+}
+
+func (d *Digest) Write(b []byte) (int, error) {
+	n := len(b)
+	d.total += uint64(n)
+
+	if d.n+n < 32 {
+		d.n += copy(d.mem[d.n:], b)
+		return n, nil
+	}
+
+	if d.n > 0 {
+		c := copy(d.mem[d.n:], b)
+		d.v1 = round(d.v1, binary.LittleEndian.Uint64(d.mem[0:8]))
+		d.v2 = round(d.v2, binary.LittleEndian.Uint64(d.mem[8:16]))
+		d.v3 = round(d.v3, binary.LittleEndian.Uint64(d.mem[16:24]))
+		d.v4 = round(d.v4, binary.LittleEndian.Uint64(d.mem[24:32]))
+		b = b[c:]
+		d.n = 0
+	}
+
+	for ; len(b) >= 32; b = b[32:] {
+		d.v1 = round(d.v1, binary.LittleEndian.Uint64(b[0:8]))
+		d.v2 = round(d.v2, binary.LittleEndian.Uint64(b[8:16]))
+		d.v3 = round(d.v3, binary.LittleEndian.Uint64(b[16:24]))
+		d.v4 = round(d.v4, binary.LittleEndian.Uint64(b[24:32]))
+	}
+	d.n = copy(d.mem[:], b)
+
+	return n, nil
+}
+
+func (d *Digest) Sum64() uint64 {
+	var h uint64
+	if d.total >= 32 {
+		h = bits.RotateLeft64(d.v1, 1) + bits.RotateLeft64(d.v2, 7) + bits.RotateLeft64(d.v3, 12) + bits.RotateLeft64(d.v4, 18)
+		h = mergeRound(h, d.v1)
+		h = mergeRound(h, d.v2)
+		h = mergeRound(h, d.v3)
+		h = mergeRound(h, d.v4)
+	} else {
+		h = d.v3 + prime5
+	}
+	h += d.total
+
+	b := d.mem[:d.n]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= round(0, binary.LittleEndian.Uint64(b[:8]))
+		h = bits.RotateLeft64(h, 27)*prime1 + prime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b[:4])) * prime1
+		h = bits.RotateLeft64(h, 23)*prime2 + prime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * prime5
+		h = bits.RotateLeft64(h, 11) * prime1
+	}
+
+	h ^= h >> 33
+	h *= prime2
+	h ^= h >> 29
+	h *= prime3
+	h ^= h >> 32
+	return h
+}
+
+// Sum64 returns XXH64 of b.
+func Sum64(b []byte) uint64 {
+	var d Digest
+	d.Reset()
+	d.Write(b)
+	return d.Sum64()
+}
+
+func round(acc, input uint64) uint64 {
+	acc += input * prime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime1
+}
+
+func mergeRound(acc, val uint64) uint64 {
+	val = round(0, val)
+	acc ^= val
+	return acc*prime1 + prime4
+}
@@ -0,0 +1,57 @@
+package xxh64
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestSum64(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+	}{
+		{"", 0xEF46DB3751D8E999},
+		{"a", 0xD24EC4F1A98C6E5B},
+		{"abc", 0x44BC2CF5AD770999},
+	}
+	for _, test := range tests {
+		if got := Sum64([]byte(test.input)); got != test.expected {
+			t.Errorf("Sum64(%q) = %#x, expected %#x", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestSum64MatchesZstdChecksum(t *testing.T) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderCRC(true))
+	if err != nil {
+		t.Fatalf("failed to create zstd encoder: %v", err)
+	}
+	defer encoder.Close()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, size := range []int{1, 7, 31, 32, 33, 100, 1000, 65537} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(rng.IntN(16))
+		}
+		frame := encoder.EncodeAll(data, nil)
+		expected := binary.LittleEndian.Uint32(frame[len(frame)-4:])
+		if got := uint32(Sum64(data)); got != expected {
+			t.Fatalf("checksum of %d bytes is %#x, zstd frame checksum is %#x", size, got, expected)
+		}
+
+		// Incremental hashing in uneven chunks
+		d := New()
+		for chunk := data; len(chunk) > 0; {
+			n := min(len(chunk), 1+rng.IntN(40))
+			d.Write(chunk[:n])
+			chunk = chunk[n:]
+		}
+		if got := uint32(d.Sum64()); got != expected {
+			t.Fatalf("incremental checksum of %d bytes is %#x, expected %#x", size, got, expected)
+		}
+	}
+}
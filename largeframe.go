@@ -0,0 +1,119 @@
+package szstd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+// DefaultStreamingThreshold is the decompressed frame size above which `ReadSeeker` decodes frames gradually.
+// See `WithStreamingThreshold`.
+const DefaultStreamingThreshold = 16 * 1024 * 1024
+
+// streamWindowSize is the size of the decompressed data of a large frame kept in memory.
+const streamWindowSize = 1024 * 1024
+
+// isLargeFrame tells whether the frame is decoded in windows instead of all at once.
+func (r *ReadSeeker) isLargeFrame(entry seektable.TableEntry) bool {
+	return int64(entry.DecompressedSize) > r.options.streamingThreshold
+}
+
+// startWindowed makes the current frame available for reading without decoding it yet. Windows are decoded by
+// `fillWindow` as they are read, or filled with zeros.
+func (r *ReadSeeker) startWindowed(entry seektable.TableEntry, zeroFill bool) {
+	r.currentFrameLoaded = true
+	r.currentFrameAvailable = int(entry.DecompressedSize)
+	r.currentFrameBuffer = r.reusableFrameBuffer()
+	r.windowed = true
+	r.windowStart = 0
+	r.windowZeroFill = zeroFill
+	r.streamOffset = -1
+}
+
+// fillWindow makes the data at the read position of a large frame available in the current frame buffer. The frame
+// is decoded from the current stream position, or from its start if the read position is behind it. Data before the
+// read position is discarded.
+func (r *ReadSeeker) fillWindow() *FrameError {
+	position := r.currentFrameReaded
+	if !r.windowed || (position >= r.windowStart && position < r.windowStart+len(r.currentFrameBuffer)) {
+		return nil
+	}
+	size := min(streamWindowSize, r.currentFrameAvailable-position)
+	r.currentFrameBuffer = slices.Grow(r.currentFrameBuffer[:0], size)[:size]
+	r.windowStart = position
+	if r.windowZeroFill {
+		clear(r.currentFrameBuffer)
+		return nil
+	}
+
+	if err := r.decodeWindow(position); err != nil {
+		r.currentFrameBuffer = r.currentFrameBuffer[:0]
+		r.streamOffset = -1
+		return r.frameError(r.currentFrameIndex, errors.Join(errors.New("failed to decode frame"), err))
+	}
+	return nil
+}
+
+// decodeWindow decodes the window at the position of the current frame into the current frame buffer.
+func (r *ReadSeeker) decodeWindow(position int) error {
+	if r.streamOffset < 0 || r.streamOffset > position {
+		if err := r.startStream(); err != nil {
+			return err
+		}
+	}
+
+	if discarded, err := io.CopyN(io.Discard, r.streamDecoder, int64(position-r.streamOffset)); err != nil {
+		return frameEndError(err, r.streamOffset+int(discarded))
+	}
+	n, err := io.ReadFull(r.streamDecoder, r.currentFrameBuffer)
+	if err != nil {
+		return frameEndError(err, position+n)
+	}
+	r.streamOffset = position + n
+
+	// Reading past the end of the frame checks its size and zstd checksum
+	if r.streamOffset == r.currentFrameAvailable {
+		var extra [1]byte
+		if n, err := r.streamDecoder.Read(extra[:]); n > 0 {
+			return errors.Join(ErrFrameSizeMismatch, fmt.Errorf("frame has more than %d bytes", r.currentFrameAvailable))
+		} else if err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
+
+// startStream starts decoding the current frame from its start.
+func (r *ReadSeeker) startStream() error {
+	if r.streamDecoder == nil {
+		decoder, err := newDecoder(r.options)
+		if err != nil {
+			return err
+		}
+		r.streamDecoder = decoder
+	}
+
+	readerAt, ok := r.r.(io.ReaderAt)
+	if !ok {
+		readerAt = &readSeekerAt{r: r.r}
+	}
+	frame := r.Frame(r.currentFrameIndex)
+	section := io.NewSectionReader(readerAt, int64(frame.EntryOffsetInCompressed), int64(frame.CompressedSize))
+	if err := r.streamDecoder.Reset(bufio.NewReaderSize(section, 64*1024)); err != nil {
+		return err
+	}
+	r.streamOffset = 0
+	return nil
+}
+
+// frameEndError describes an error of a frame whose decoding stopped after size bytes.
+func frameEndError(err error, size int) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.Join(ErrFrameSizeMismatch, fmt.Errorf("frame ended after %d bytes", size))
+	}
+	return err
+}
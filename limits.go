@@ -0,0 +1,65 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// checkFrame rejects the frame if its seek table entry exceeds the limits set by `WithMaxFrameSize`,
+// `WithMaxDecompressedSize` or `WithMaxCompressionRatio`. Nothing is read or decoded for that.
+func (o readerOptions) checkFrame(frame Frame) error {
+	if o.maxFrameSize > 0 && (int64(frame.CompressedSize) > o.maxFrameSize || int64(frame.DecompressedSize) > o.maxFrameSize) {
+		return fmt.Errorf("%w: frame has %d compressed and %d decompressed bytes, limit is %d",
+			ErrFrameSizeLimit, frame.CompressedSize, frame.DecompressedSize, o.maxFrameSize)
+	}
+	if o.maxDecompressedSize > 0 && frame.DecompressedRange().End > uint64(o.maxDecompressedSize) {
+		return fmt.Errorf("%w: frame ends at decompressed offset %d, limit is %d",
+			ErrDecompressedSizeLimit, frame.DecompressedRange().End, o.maxDecompressedSize)
+	}
+	if o.maxCompressionRatio > 0 && float64(frame.DecompressedSize) > o.maxCompressionRatio*float64(frame.CompressedSize) {
+		return fmt.Errorf("%w: frame decompresses %d bytes to %d, limit is %g",
+			ErrCompressionRatioLimit, frame.CompressedSize, frame.DecompressedSize, o.maxCompressionRatio)
+	}
+	return nil
+}
+
+// checkTable rejects the archive if its seek table declares more decompressed data than `WithMaxDecompressedSize`
+// allows.
+func (o readerOptions) checkTable(table *seektable.Table) error {
+	if o.maxDecompressedSize <= 0 {
+		return nil
+	}
+	if size := decompressedSize(table); size > uint64(o.maxDecompressedSize) {
+		return fmt.Errorf("%w: seek table declares %d bytes, limit is %d", ErrDecompressedSizeLimit, size, o.maxDecompressedSize)
+	}
+	return nil
+}
+
+// newTableDecoder creates a decoder for `decodeEntry`, which stops decoding when the output exceeds the capacity
+// of the destination.
+func newTableDecoder(o readerOptions) (*zstd.Decoder, error) {
+	return newDecoder(o, zstd.WithDecodeAllCapLimit(true))
+}
+
+// decodeEntry decodes the frame described by the seek table entry and appends the result to dst. The frame must
+// decode to exactly the size declared by the entry; decoding stops as soon as it exceeds it, so frames cannot expand
+// beyond their entry. The decoder must be created by `newTableDecoder`.
+func decodeEntry(decoder *zstd.Decoder, src []byte, entry seektable.TableEntry, dst []byte) ([]byte, error) {
+	start, size := len(dst), int(entry.DecompressedSize)
+	dst = slices.Grow(dst, size)
+	decoded, err := decoder.DecodeAll(src, dst[:start:start+size])
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return dst, errors.Join(ErrFrameSizeMismatch, fmt.Errorf("frame decompresses to more than %d bytes", size))
+	}
+	if err != nil {
+		return dst, errors.Join(errors.New("failed to decode frame"), err)
+	}
+	if len(decoded)-start != size {
+		return dst, errors.Join(ErrFrameSizeMismatch, fmt.Errorf("decompressed %d bytes, expected %d", len(decoded)-start, size))
+	}
+	return dst[:start+size], nil // decoded in place, keeps capacity of dst
+}
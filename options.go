@@ -0,0 +1,288 @@
+package szstd
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// WriterOption is an option for creating a seekable writer.
+type WriterOption func(*writerOptions) error
+
+type writerOptions struct {
+	concurrency    int
+	checksums      bool
+	encoderOptions []zstd.EOption
+
+	headTable   bool
+	headTempDir string
+
+	streamingFrames bool
+}
+
+func defaultWriterOptions() writerOptions {
+	return writerOptions{
+		concurrency: 1,
+	}
+}
+
+func applyWriterOptions(opts []WriterOption) (writerOptions, error) {
+	o := defaultWriterOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, errors.Join(errors.New("invalid writer option"), err)
+		}
+	}
+	return o, nil
+}
+
+// WithEncoderOptions passes options to the zstd encoders used to compress frames.
+func WithEncoderOptions(opts ...zstd.EOption) WriterOption {
+	return func(o *writerOptions) error {
+		o.encoderOptions = append(o.encoderOptions, opts...)
+		return nil
+	}
+}
+
+// WithWriterConcurrency sets the number of frames compressed in parallel.
+// Frames are still written in order. If n is 0, GOMAXPROCS is used. Default is 1.
+// Every concurrently compressed frame holds its own copy of the frame data.
+func WithWriterConcurrency(n int) WriterOption {
+	return func(o *writerOptions) error {
+		if n < 0 {
+			return errors.New("concurrency must not be negative")
+		}
+		if n == 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		o.concurrency = n
+		return nil
+	}
+}
+
+// WithChecksums stores checksum of every decompressed frame in the seek table. Default is false.
+// Checksums let readers verify frames independently of the zstd frame checksums.
+func WithChecksums(enabled bool) WriterOption {
+	return func(o *writerOptions) error {
+		o.checksums = enabled
+		return nil
+	}
+}
+
+// WithHeadSeekTable also places a copy of the seek table at the beginning of the archive, so `NewReader` can
+// report progress and skip frames when reading from a non-seekable stream. The standard seek table at the end is
+// still written. The head copy is a seek table frame recorded as the first entry of the table, with no
+// decompressed data, so other readers treat it as an ordinary skippable frame.
+//
+// Since the seek table is known only when closing, frames are first written into a temporary file in tempDir
+// (`os.TempDir` if empty) and copied to the destination by `Close`. The file is removed only by `Close`, so it
+// must be called even if writing fails.
+func WithHeadSeekTable(tempDir string) WriterOption {
+	return func(o *writerOptions) error {
+		o.headTable = true
+		o.headTempDir = tempDir
+		return nil
+	}
+}
+
+// WithStreamingFrames compresses frames with a streaming encoder straight into the output, instead of collecting
+// every frame in memory and compressing it at once. Memory use then depends on the encoder window only, not on the
+// frame size, so archives with frames of hundreds of megabytes can be written with little memory. Frame headers do
+// not declare the decompressed size, since it is unknown when a frame starts; readers take it from the seek table.
+// Cannot be combined with `WithWriterConcurrency` above 1. Default is false.
+func WithStreamingFrames(enabled bool) WriterOption {
+	return func(o *writerOptions) error {
+		o.streamingFrames = enabled
+		return nil
+	}
+}
+
+// ReaderOption is an option for creating a seekable reader.
+type ReaderOption func(*readerOptions) error
+
+type readerOptions struct {
+	decoderOptions []zstd.DOption
+	onCorruptFrame func(*FrameError) CorruptFrameAction
+	indexKind      seektable.IndexKind
+	parseMode      seektable.ParseMode
+
+	validateSeekTable bool
+
+	frameCacheSize      int64
+	streamingThreshold  int64
+	maxFrameSize        int64 // 0 means no limit
+	maxDecompressedSize int64
+	maxCompressionRatio float64
+
+	lazyTable       bool
+	lazyPageEntries int
+	lazyCachedPages int
+}
+
+func applyReaderOptions(opts []ReaderOption) (readerOptions, error) {
+	o := defaultReaderOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, errors.Join(errors.New("invalid reader option"), err)
+		}
+	}
+	return o, nil
+}
+
+func defaultReaderOptions() readerOptions {
+	return readerOptions{
+		streamingThreshold: DefaultStreamingThreshold,
+	}
+}
+
+// WithDecoderOptions passes options to the zstd decoder used to decompress frames.
+func WithDecoderOptions(opts ...zstd.DOption) ReaderOption {
+	return func(o *readerOptions) error {
+		o.decoderOptions = append(o.decoderOptions, opts...)
+		return nil
+	}
+}
+
+// CorruptFrameAction tells the reader how to continue after a frame could not be read or decoded.
+type CorruptFrameAction int
+
+const (
+	CorruptFrameFail     CorruptFrameAction = iota // `Read` returns the `FrameError`
+	CorruptFrameZeroFill                           // data of the frame is replaced with zeros
+	CorruptFrameSkip                               // data of the frame is omitted, reading continues with the next frame
+)
+
+// WithCorruptFrameHandler calls handler for every frame that `Read` cannot read or decode, and continues as the
+// returned action says. Useful to salvage intact data from damaged archives. By default `Read` fails.
+// Skipped frames still occupy their range of offsets, so `Seek` positions are not affected.
+func WithCorruptFrameHandler(handler func(err *FrameError) CorruptFrameAction) ReaderOption {
+	return func(o *readerOptions) error {
+		o.onCorruptFrame = handler
+		return nil
+	}
+}
+
+// WithIndexKind selects the in-memory index of the seek table. `seektable.CompactIndex` uses a fraction of the
+// memory of the default `seektable.FullIndex` for archives with many frames, at the cost of slightly slower seeks.
+func WithIndexKind(kind seektable.IndexKind) ReaderOption {
+	return func(o *readerOptions) error {
+		o.indexKind = kind
+		return nil
+	}
+}
+
+// WithSeekTableParseMode selects how strictly the seek table is checked when opening the archive. Default is
+// `seektable.ParseDefault`; `seektable.ParseLenient` opens archives of other implementations with benign deviations
+// from the format.
+func WithSeekTableParseMode(mode seektable.ParseMode) ReaderOption {
+	return func(o *readerOptions) error {
+		if mode < seektable.ParseDefault || mode > seektable.ParseLenient {
+			return errors.New("unknown seek table parse mode")
+		}
+		o.parseMode = mode
+		return nil
+	}
+}
+
+// WithSeekTableValidation makes `NewReader` check frames of archives without a head seek table against the seek
+// table at the end of the archive, when it arrives. Checksums are calculated for every decoded frame for that.
+// Frames skipped without decoding are checked by size only. `Read` returns `ErrSeekTableMismatch` on differences.
+// Default is false.
+func WithSeekTableValidation(enabled bool) ReaderOption {
+	return func(o *readerOptions) error {
+		o.validateSeekTable = enabled
+		return nil
+	}
+}
+
+// WithFrameCache keeps up to size bytes of decoded frames in memory, so reading a frame again does not decode it.
+// The least recently used frames are evicted. The cache is shared by all readers of an `Archive` and by all
+// requests of a `Handler`. Frames decoded gradually (see `WithStreamingThreshold`) are not cached. Default is no
+// cache.
+func WithFrameCache(size int64) ReaderOption {
+	return func(o *readerOptions) error {
+		if size <= 0 {
+			return errors.New("frame cache size must be positive")
+		}
+		o.frameCacheSize = size
+		return nil
+	}
+}
+
+// WithStreamingThreshold sets the decompressed size above which `ReadSeeker` decodes a frame gradually instead of
+// all at once. Only a window of the decompressed frame is kept in memory, and reading stops decoding at the
+// requested offset. Seeking backwards within such a frame decodes it again from its start. Default is
+// `DefaultStreamingThreshold`.
+func WithStreamingThreshold(size int64) ReaderOption {
+	return func(o *readerOptions) error {
+		if size < 0 {
+			return errors.New("streaming threshold must not be negative")
+		}
+		o.streamingThreshold = size
+		return nil
+	}
+}
+
+// WithMaxFrameSize rejects frames whose compressed or decompressed size exceeds size bytes with
+// `ErrFrameSizeLimit`, before any memory is allocated for them. It protects against archives with seek tables
+// declaring huge frames. The limit also applies to the decoded data of frames whose size is not known in advance.
+// Rejected frames are passed to the corrupt frame handler. Default is no limit.
+func WithMaxFrameSize(size int64) ReaderOption {
+	return func(o *readerOptions) error {
+		if size <= 0 {
+			return errors.New("maximum frame size must be positive")
+		}
+		o.maxFrameSize = size
+		return nil
+	}
+}
+
+// WithMaxDecompressedSize rejects archives whose seek table declares more than size bytes of decompressed data with
+// `ErrDecompressedSizeLimit` when opening them. Lazily read seek tables are not summed up when opening; their frames
+// are rejected when reading reaches the limit instead. Default is no limit.
+func WithMaxDecompressedSize(size int64) ReaderOption {
+	return func(o *readerOptions) error {
+		if size <= 0 {
+			return errors.New("maximum decompressed size must be positive")
+		}
+		o.maxDecompressedSize = size
+		return nil
+	}
+}
+
+// WithMaxCompressionRatio rejects frames whose seek table entry declares more than ratio decompressed bytes per
+// compressed byte with `ErrCompressionRatioLimit`, before decoding them. Highly repetitive data legitimately
+// compresses well, so the limit should leave room for it. Rejected frames are passed to the corrupt frame handler.
+// Default is no limit.
+func WithMaxCompressionRatio(ratio float64) ReaderOption {
+	return func(o *readerOptions) error {
+		if !(ratio >= 1) {
+			return errors.New("maximum compression ratio must be at least 1")
+		}
+		o.maxCompressionRatio = ratio
+		return nil
+	}
+}
+
+// WithLazySeekTable reads only the seek table footer when opening the archive. Entries are read on demand in
+// pages of pageEntries entries, keeping at most cachedPages pages in memory; zero values select defaults.
+// See `seektable.ReadTableLazily`. Useful for remote archives with huge seek tables. The table is not checked
+// against the size of the data when opening.
+//
+// Offsets of entries are sums of the sizes of all preceding entries, so the first lookup of an offset reads pages
+// from the start of the table up to it. `Size` reads every entry once, and so does everything that needs it:
+// seeking relative to the end, `Archive.ReadAt` and the range check of `ReadSeeker.WriteZstdRange`. Tables that
+// are read in full anyway are better read eagerly.
+func WithLazySeekTable(pageEntries, cachedPages int) ReaderOption {
+	return func(o *readerOptions) error {
+		if pageEntries < 0 || cachedPages < 0 {
+			return errors.New("page size and number of cached pages must not be negative")
+		}
+		o.lazyTable = true
+		o.lazyPageEntries = pageEntries
+		o.lazyCachedPages = cachedPages
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package szstd
+
+import (
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// pipeline processes submitted items on multiple workers and delivers them in submission order.
+// Items must be submitted from a single goroutine.
+type pipeline[T any] struct {
+	work    chan *pipelineItem[T]
+	ordered chan *pipelineItem[T]
+
+	workers   sync.WaitGroup
+	delivered chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+type pipelineItem[T any] struct {
+	value T
+	ready chan struct{}
+}
+
+// newPipeline starts `workers` goroutines running process and a goroutine calling deliver in submission order.
+// After deliver returns an error, the remaining items are processed but not delivered.
+func newPipeline[T any](workers int, process func(worker int, item *T), deliver func(item *T) error) *pipeline[T] {
+	p := &pipeline[T]{
+		work:      make(chan *pipelineItem[T], workers),
+		ordered:   make(chan *pipelineItem[T], workers*2),
+		delivered: make(chan struct{}),
+	}
+
+	for worker := 0; worker < workers; worker++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			for item := range p.work {
+				process(worker, &item.value)
+				close(item.ready)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(p.delivered)
+		for item := range p.ordered {
+			<-item.ready
+			if p.Err() != nil {
+				continue
+			}
+			if err := deliver(&item.value); err != nil {
+				p.mu.Lock()
+				p.err = err
+				p.mu.Unlock()
+			}
+		}
+	}()
+
+	return p
+}
+
+// Submit queues the item. Blocks while too many items are in flight.
+// Returns the first delivery error, if any.
+func (p *pipeline[T]) Submit(value T) error {
+	if err := p.Err(); err != nil {
+		return err
+	}
+	item := &pipelineItem[T]{value: value, ready: make(chan struct{})}
+	p.ordered <- item
+	p.work <- item
+	return nil
+}
+
+// Err returns the first delivery error.
+func (p *pipeline[T]) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Close waits until all submitted items are delivered and returns the first delivery error.
+func (p *pipeline[T]) Close() error {
+	close(p.ordered)
+	close(p.work)
+	<-p.delivered
+	p.workers.Wait()
+	return p.Err()
+}
+
+// bufferPool keeps a bounded number of reusable buffers.
+type bufferPool struct {
+	free chan []byte
+	size int // capacity of newly allocated buffers
+}
+
+func newBufferPool(buffers, size int) *bufferPool {
+	return &bufferPool{free: make(chan []byte, buffers), size: size}
+}
+
+// Get returns an empty buffer.
+func (p *bufferPool) Get() []byte {
+	select {
+	case buffer := <-p.free:
+		return buffer[:0]
+	default:
+		return make([]byte, 0, p.size)
+	}
+}
+
+// Put returns the buffer to the pool. Buffer is dropped if the pool is full.
+func (p *bufferPool) Put(buffer []byte) {
+	select {
+	case p.free <- buffer:
+	default:
+	}
+}
+
+// decoderPool keeps a bounded number of reusable zstd decoders for `decodeEntry`. Decoders that do not fit are
+// closed.
+type decoderPool struct {
+	free    chan *zstd.Decoder
+	options readerOptions
+}
+
+func newDecoderPool(decoders int, o readerOptions) *decoderPool {
+	return &decoderPool{free: make(chan *zstd.Decoder, decoders), options: o}
+}
+
+// Get returns a pooled decoder or creates a new one.
+func (p *decoderPool) Get() (*zstd.Decoder, error) {
+	select {
+	case decoder := <-p.free:
+		return decoder, nil
+	default:
+		return newTableDecoder(p.options)
+	}
+}
+
+// Put returns the decoder to the pool.
+func (p *decoderPool) Put(decoder *zstd.Decoder) {
+	select {
+	case p.free <- decoder:
+	default:
+		decoder.Close()
+	}
+}
+
+// Close closes all pooled decoders.
+func (p *decoderPool) Close() {
+	for {
+		select {
+		case decoder := <-p.free:
+			decoder.Close()
+		default:
+			return
+		}
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"slices"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/opengs/szstd/seektable"
 )
 
-type reader struct {
+// ReadSeeker decompresses a seekable archive with random access.
+// Besides `io.ReadSeekCloser`, it gives access to individual frames. It is not safe for concurrent use.
+type ReadSeeker struct {
 	r io.ReadSeeker
 
 	decoder   *zstd.Decoder
@@ -18,33 +22,48 @@ type reader struct {
 	offset uint64
 
 	totalCompressedDataSize   uint64 // without seek table
-	totalUncompressedDataSize uint64
+	totalUncompressedDataSize uint64 // calculated on first use, since it needs all entries of a lazily read table
+	uncompressedSizeKnown     bool
 
 	currentFrameIndex     int
 	currentFrameLoaded    bool
 	currentFrameBuffer    []byte
-	currentFrameReaded    int // number of bytes already readed from the current frame buffer
-	currentFrameAvailable int // total number of bytes available in the current frame buffer (readed + un-readed)
+	currentFrameCached    bool // current frame buffer belongs to the frame cache and must not be reused
+	currentFrameReaded    int  // number of bytes already readed from the current frame buffer
+	currentFrameAvailable int  // total number of bytes available in the current frame buffer (readed + un-readed)
 
 	compressedDataBuffer []byte
+
+	// Frames larger than the streaming threshold are decoded gradually, keeping a window of them in
+	// currentFrameBuffer (see largeframe.go)
+	windowed       bool
+	windowStart    int  // offset of currentFrameBuffer in the current frame
+	windowZeroFill bool // windows are filled with zeros instead of decoded data
+	streamDecoder  *zstd.Decoder
+	streamOffset   int // offset in the current frame of the next byte from streamDecoder, -1 if not started
+
+	archive *Archive    // archive the reader was created from, lends decoders if decoder is nil
+	cache   *frameCache // nil if frames are not cached
+
+	options        readerOptions
+	onCorruptFrame func(*FrameError) CorruptFrameAction
 }
 
-func NewReadSeeker(r io.ReadSeeker, opts ...zstd.DOption) (io.ReadSeekCloser, error) {
-	decoder, err := zstd.NewReader(nil, append([]zstd.DOption{zstd.WithDecoderConcurrency(1)}, opts...)...)
-	if err != nil {
-		return nil, errors.Join(errors.New("failed to create zstd decoder"), err)
-	}
+// NewReadSeeker reads the seek table at the end of r and returns a reader of its decompressed data.
+//
+// NewReadSeeker only takes decoder options. It is the same as `NewReadSeekerWithOptions` with `WithDecoderOptions`,
+// which should be used to configure anything else.
+func NewReadSeeker(r io.ReadSeeker, opts ...zstd.DOption) (*ReadSeeker, error) {
+	return NewReadSeekerWithOptions(r, WithDecoderOptions(opts...))
+}
 
-	seekTable, err := seektable.ReadTableFromReadSeeker(r)
+// NewReadSeekerWithOptions creates a reader as `NewReadSeeker`, configured with reader options.
+func NewReadSeekerWithOptions(r io.ReadSeeker, opts ...ReaderOption) (*ReadSeeker, error) {
+	o, err := applyReaderOptions(opts)
 	if err != nil {
-		return nil, errors.Join(errors.New("failed to read seek table"), err)
+		return nil, err
 	}
 
-	// Calculate total uncompressed size
-	lastOffsets := seekTable.OffsetsByIndex(seekTable.NumEntries() - 1)
-	lastEntry := seekTable.GetEntry(seekTable.NumEntries() - 1)
-	totalUncompressedDataSize := lastOffsets.EntryOffsetInDecompressed + uint64(lastEntry.DecompressedSize)
-
 	// Calculate total compressed size
 	_, err = r.Seek(0, io.SeekStart)
 	if err != nil {
@@ -54,54 +73,131 @@ func NewReadSeeker(r io.ReadSeeker, opts ...zstd.DOption) (io.ReadSeekCloser, er
 	if err != nil {
 		return nil, errors.Join(errors.New("failed to seek to end to calculate total compressed size"), err)
 	}
+
+	seekTable, err := readSeekTable(r, totalDataSize, o)
+	if err != nil {
+		return nil, err
+	}
 	totalCompressedDataSize := uint64(totalDataSize) - uint64(seekTable.Size())
 
+	decoder, err := newTableDecoder(o)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newReadSeeker(r, seekTable, totalCompressedDataSize, decoder, o)
+	reader.cache = newFrameCache(o.frameCacheSize)
+	return reader, nil
+}
+
+// readSeekTable reads the seek table at the end of r, which has the given size, as selected by the options.
+// Lazily read tables are not checked against the data size, since that needs all entries.
+func readSeekTable(r io.ReadSeeker, size int64, o readerOptions) (*seektable.Table, error) {
+	var seekTable *seektable.Table
+	var err error
+	if o.lazyTable {
+		readerAt, ok := r.(io.ReaderAt)
+		if !ok {
+			readerAt = &readSeekerAt{r: r}
+		}
+		seekTable, err = seektable.ReadTableLazily(readerAt, size, o.lazyPageEntries, o.lazyCachedPages, seektable.WithParseMode(o.parseMode))
+	} else {
+		seekTable, err = seektable.ReadTableFromReadSeeker(r, seektable.WithParseMode(o.parseMode))
+	}
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read seek table"), err)
+	}
+	seekTable.SetIndexKind(o.indexKind)
+
 	// Make sure the seek table is consistent with the underlying reader size
-	if seekTable.NumEntries() > 0 {
-		expectedSize := uint64(lastOffsets.EntryOffsetInCompressed) + uint64(lastEntry.CompressedSize)
+	if !o.lazyTable && seekTable.NumEntries() > 0 {
+		expectedSize := framesEnd(seekTable)
+		totalCompressedDataSize := uint64(size) - uint64(seekTable.Size())
 		if totalCompressedDataSize < expectedSize { // size can be greater because of possible empty frames as per ZSTD spec
 			return nil, fmt.Errorf("seek table last entry size mismatch: expected total compressed size %d, got %d", expectedSize, totalCompressedDataSize)
 		}
+		if err := o.checkTable(seekTable); err != nil {
+			return nil, err
+		}
 	}
+	return seekTable, nil
+}
 
-	return &reader{r: r, decoder: decoder, seekTable: seekTable, totalUncompressedDataSize: totalUncompressedDataSize, totalCompressedDataSize: totalCompressedDataSize}, nil
+// readSeekerAt implements io.ReaderAt by seeking. It is not safe for concurrent use.
+type readSeekerAt struct {
+	r io.ReadSeeker
 }
 
-func (r *reader) Read(p []byte) (int, error) {
-	if r.currentFrameIndex >= r.seekTable.NumEntries() {
-		return 0, io.EOF
+func (r *readSeekerAt) ReadAt(p []byte, offset int64) (int, error) {
+	if _, err := r.r.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
 	}
+	return io.ReadFull(r.r, p)
+}
 
-	if !r.currentFrameLoaded {
-		tableOffsets, offsetFounded := r.seekTable.Find(r.offset)
-		if !offsetFounded {
-			return 0, fmt.Errorf("failed to find frame for offset %d", r.offset)
+// newReadSeeker creates a reader of an archive whose seek table is already read and checked.
+// Table can be shared between readers.
+func newReadSeeker(r io.ReadSeeker, seekTable *seektable.Table, totalCompressedDataSize uint64, decoder *zstd.Decoder, o readerOptions) *ReadSeeker {
+	return &ReadSeeker{
+		r:                       r,
+		decoder:                 decoder,
+		seekTable:               seekTable,
+		totalCompressedDataSize: totalCompressedDataSize,
+		options:                 o,
+		onCorruptFrame:          o.onCorruptFrame,
+	}
+}
+
+// newDecoder creates a decoder with the options of the reader, and any additional options.
+func newDecoder(o readerOptions, opts ...zstd.DOption) (*zstd.Decoder, error) {
+	decoderOptions := append([]zstd.DOption{zstd.WithDecoderConcurrency(1)}, opts...)
+	if o.maxFrameSize > 0 {
+		decoderOptions = append(decoderOptions, zstd.WithDecoderMaxMemory(uint64(o.maxFrameSize)))
+	}
+	decoder, err := zstd.NewReader(nil, append(decoderOptions, o.decoderOptions...)...)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to create zstd decoder"), err)
+	}
+	return decoder, nil
+}
+
+func (r *ReadSeeker) Read(p []byte) (int, error) {
+	for {
+		if r.currentFrameIndex >= r.seekTable.NumEntries() {
+			return 0, io.EOF
 		}
-		if _, err := r.r.Seek(int64(tableOffsets.EntryOffsetInCompressed), io.SeekStart); err != nil {
-			return 0, errors.Join(fmt.Errorf("failed to seek to the frame offset %d", r.offset), err)
+		if r.currentFrameLoaded {
+			err := r.fillWindow()
+			if err == nil {
+				break
+			}
+			if !r.handleCorruptFrame(err) {
+				return 0, err
+			}
+			continue
 		}
-		entry := r.seekTable.GetEntry(tableOffsets.EntryIndex)
-		if uint64(entry.CompressedSize) > uint64(cap(r.compressedDataBuffer)) {
-			r.compressedDataBuffer = make([]byte, entry.CompressedSize)
-		} else {
-			r.compressedDataBuffer = r.compressedDataBuffer[:entry.CompressedSize]
+
+		tableOffsets, offsetFounded := r.seekTable.Find(r.offset)
+		if err := r.tableError(); err != nil {
+			return 0, err
 		}
-		if _, err := io.ReadFull(r.r, r.compressedDataBuffer); err != nil {
-			return 0, errors.Join(fmt.Errorf("failed to read compressed frame data for offset %d", r.offset), err)
+		if !offsetFounded {
+			// Only entries without decompressed data, such as the head seek table, follow the end
+			if size := r.Size(); size >= 0 && r.offset >= uint64(size) {
+				return 0, io.EOF
+			}
+			if err := r.tableError(); err != nil {
+				return 0, err
+			}
+			return 0, fmt.Errorf("failed to find frame for offset %d", r.offset)
 		}
-
-		var err error
-		r.currentFrameBuffer, err = r.decoder.DecodeAll(r.compressedDataBuffer, r.currentFrameBuffer[:0])
-		if err != nil {
-			return 0, errors.Join(fmt.Errorf("failed to decode frame for offset %d", r.offset), err)
+		r.currentFrameIndex = tableOffsets.EntryIndex
+		if err := r.loadFrame(tableOffsets.EntryIndex); err != nil && !r.handleCorruptFrame(err) {
+			return 0, err
 		}
-		r.currentFrameLoaded = true
-		r.currentFrameAvailable = len(r.currentFrameBuffer)
 	}
 
-	availableToRead := r.currentFrameAvailable - r.currentFrameReaded
-	toRead := min(len(p), availableToRead)
-	copy(p[:toRead], r.currentFrameBuffer[r.currentFrameReaded:r.currentFrameReaded+toRead])
+	toRead := copy(p, r.currentFrameBuffer[r.currentFrameReaded-r.windowStart:])
 	r.currentFrameReaded += toRead
 	r.offset += uint64(toRead)
 
@@ -116,7 +212,83 @@ func (r *reader) Read(p []byte) (int, error) {
 	return toRead, nil
 }
 
-func (r *reader) Seek(offset int64, whence int) (int64, error) {
+// handleCorruptFrame continues reading after the current frame failed as the corrupt frame handler says.
+// Returns false if reading must fail.
+func (r *ReadSeeker) handleCorruptFrame(err *FrameError) bool {
+	action := CorruptFrameFail
+	if r.onCorruptFrame != nil {
+		action = r.onCorruptFrame(err)
+	}
+	switch action {
+	case CorruptFrameZeroFill:
+		if r.isLargeFrame(err.TableEntry) {
+			r.startWindowed(err.TableEntry, true)
+			return true
+		}
+		size := int(err.DecompressedSize)
+		r.currentFrameBuffer = slices.Grow(r.reusableFrameBuffer(), size)[:size]
+		clear(r.currentFrameBuffer)
+		r.currentFrameLoaded = true
+		r.currentFrameAvailable = size
+		r.windowed = false
+		r.windowStart = 0
+	case CorruptFrameSkip:
+		r.currentFrameIndex++
+		r.currentFrameLoaded = false
+		r.currentFrameReaded = 0
+		r.offset = err.DecompressedRange().End
+	default:
+		return false
+	}
+	return true
+}
+
+// loadFrame reads and decodes the frame into the current frame buffer. Large frames are only prepared for
+// decoding in windows.
+func (r *ReadSeeker) loadFrame(index int) *FrameError {
+	frame := r.Frame(index)
+	if r.isLargeFrame(frame.TableEntry) {
+		if err := r.options.checkFrame(frame); err != nil {
+			return r.frameError(index, err)
+		}
+		r.startWindowed(frame.TableEntry, false)
+		return nil
+	}
+	if data, ok := r.cache.get(index); ok {
+		r.currentFrameBuffer = data
+		r.currentFrameCached = true
+		r.currentFrameLoaded = true
+		r.currentFrameAvailable = len(data)
+		r.windowed = false
+		r.windowStart = 0
+		return nil
+	}
+
+	var err error
+	r.compressedDataBuffer, err = r.readRawFrame(index, r.compressedDataBuffer[:0])
+	if err != nil {
+		return r.frameError(index, err)
+	}
+	dst := r.reusableFrameBuffer()
+	if r.cache != nil {
+		dst = nil // decoded frame is handed over to the cache
+	}
+	r.currentFrameBuffer, err = r.decodeEntry(r.compressedDataBuffer, frame.TableEntry, dst)
+	if err != nil {
+		return r.frameError(index, err)
+	}
+	if r.cache != nil {
+		r.cache.put(index, r.currentFrameBuffer)
+		r.currentFrameCached = true
+	}
+	r.currentFrameLoaded = true
+	r.currentFrameAvailable = len(r.currentFrameBuffer)
+	r.windowed = false
+	r.windowStart = 0
+	return nil
+}
+
+func (r *ReadSeeker) Seek(offset int64, whence int) (int64, error) {
 	// Calculate the new offset
 	var newOffset uint64
 	switch whence {
@@ -134,21 +306,32 @@ func (r *reader) Seek(offset int64, whence int) (int64, error) {
 		}
 		newOffset = r.offset + uint64(offset)
 	case io.SeekEnd:
-		if offset < 0 && uint64(-offset) > r.totalUncompressedDataSize {
+		if r.Size() < 0 {
+			return 0, r.tableError()
+		}
+		size := uint64(r.Size())
+		if offset < 0 && uint64(-offset) > size {
 			return 0, errors.New("negative offset")
 		}
-		newOffset = r.totalUncompressedDataSize + uint64(offset)
+		newOffset = size + uint64(offset)
 	default:
 		return 0, errors.New("invalid whence")
 	}
 
-	if newOffset > r.totalUncompressedDataSize {
-		return 0, errors.New("offset beyond end of data")
-	}
-
 	tableOffsets, found := r.seekTable.Find(newOffset)
+	if err := r.tableError(); err != nil {
+		return 0, err
+	}
 	if !found {
-		return 0, errors.New("offset beyond end of data")
+		if newOffset != uint64(r.Size()) {
+			return 0, errors.New("offset beyond end of data")
+		}
+		// No frame contains the end of data, next Read returns io.EOF
+		r.currentFrameIndex = r.seekTable.NumEntries()
+		r.currentFrameLoaded = false
+		r.currentFrameReaded = 0
+		r.offset = newOffset
+		return int64(newOffset), nil
 	}
 	frameStartOffset := tableOffsets.EntryOffsetInDecompressed
 
@@ -163,7 +346,180 @@ func (r *reader) Seek(offset int64, whence int) (int64, error) {
 	return int64(newOffset), nil
 }
 
-func (r *reader) Close() error {
-	r.decoder.Close()
+// Close releases decoders of the reader. Readers created by `Archive.NewCursor` leave the archive open.
+func (r *ReadSeeker) Close() error {
+	if r.decoder != nil {
+		r.decoder.Close()
+	}
+	if r.streamDecoder != nil {
+		r.streamDecoder.Close()
+	}
 	return nil
 }
+
+// Size returns the total decompressed size of the archive, or -1 if entries of a lazily read seek table cannot be
+// read. The error is then returned by `Read`, `Seek` and the frame methods.
+func (r *ReadSeeker) Size() int64 {
+	if r.archive != nil {
+		return r.archive.Size()
+	}
+	if !r.uncompressedSizeKnown {
+		size := decompressedSize(r.seekTable)
+		if r.seekTable.Err() != nil {
+			return -1
+		}
+		r.totalUncompressedDataSize = size
+		r.uncompressedSizeKnown = true
+	}
+	return int64(r.totalUncompressedDataSize)
+}
+
+// NumFrames returns the number of frames in the archive.
+func (r *ReadSeeker) NumFrames() int {
+	return r.seekTable.NumEntries()
+}
+
+// Frame returns position and sizes of the frame with the given index. The frame is zero if its entry of a lazily
+// read seek table cannot be read; `RawFrame` and `DecodeFrame` return the error.
+func (r *ReadSeeker) Frame(index int) Frame {
+	return Frame{TableOffset: r.seekTable.OffsetsByIndex(index), TableEntry: r.seekTable.GetEntry(index)}
+}
+
+// Frames iterates over metadata of all frames.
+func (r *ReadSeeker) Frames() iter.Seq2[int, Frame] {
+	return func(yield func(int, Frame) bool) {
+		for i := 0; i < r.NumFrames(); i++ {
+			if !yield(i, r.Frame(i)) {
+				return
+			}
+		}
+	}
+}
+
+// RawFrame returns the compressed bytes of the frame. The frame can be copied verbatim into another zstd stream.
+func (r *ReadSeeker) RawFrame(index int) ([]byte, error) {
+	if err := r.checkFrameIndex(index); err != nil {
+		return nil, err
+	}
+	raw, err := r.readRawFrame(index, nil)
+	if err != nil {
+		return nil, r.frameError(index, err)
+	}
+	return raw, nil
+}
+
+// DecodeFrame decompresses the frame and appends the result to dst.
+func (r *ReadSeeker) DecodeFrame(index int, dst []byte) ([]byte, error) {
+	if err := r.checkFrameIndex(index); err != nil {
+		return dst, err
+	}
+	var err error
+	r.compressedDataBuffer, err = r.readRawFrame(index, r.compressedDataBuffer[:0])
+	if err != nil {
+		return dst, r.frameError(index, err)
+	}
+	dst, err = r.decodeEntry(r.compressedDataBuffer, r.seekTable.GetEntry(index), dst)
+	if err != nil {
+		return dst, r.frameError(index, err)
+	}
+	return dst, nil
+}
+
+// RawFrames iterates over compressed bytes of all frames in order.
+// The slice is reused between iterations. Iteration stops after the first error.
+func (r *ReadSeeker) RawFrames() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		var buffer []byte
+		for i := 0; i < r.NumFrames(); i++ {
+			var err error
+			buffer, err = r.readRawFrame(i, buffer[:0])
+			if err != nil {
+				yield(nil, r.frameError(i, err))
+				return
+			}
+			if !yield(buffer, nil) {
+				return
+			}
+		}
+	}
+}
+
+// DecodedFrames iterates over decompressed data of all frames in order.
+// The slice is reused between iterations. Iteration stops after the first error.
+func (r *ReadSeeker) DecodedFrames() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		var buffer []byte
+		for i := 0; i < r.NumFrames(); i++ {
+			var err error
+			buffer, err = r.DecodeFrame(i, buffer[:0])
+			if !yield(buffer, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readRawFrame appends compressed bytes of the frame to dst.
+// Panics if index is out of range.
+func (r *ReadSeeker) readRawFrame(index int, dst []byte) ([]byte, error) {
+	tableOffsets := r.seekTable.OffsetsByIndex(index)
+	entry := r.seekTable.GetEntry(index)
+	if err := r.tableError(); err != nil {
+		return dst, err
+	}
+	if err := r.options.checkFrame(Frame{TableOffset: tableOffsets, TableEntry: entry}); err != nil {
+		return dst, err
+	}
+
+	if _, err := r.r.Seek(int64(tableOffsets.EntryOffsetInCompressed), io.SeekStart); err != nil {
+		return dst, errors.Join(errors.New("failed to seek to frame"), err)
+	}
+	dst = slices.Grow(dst, int(entry.CompressedSize))
+	frame := dst[len(dst) : len(dst)+int(entry.CompressedSize)]
+	if _, err := io.ReadFull(r.r, frame); err != nil {
+		return dst, errors.Join(errors.New("failed to read frame"), err)
+	}
+	return dst[:len(dst)+len(frame)], nil
+}
+
+// decodeEntry decodes the frame with the decoder of the reader, or one borrowed from its archive.
+func (r *ReadSeeker) decodeEntry(src []byte, entry seektable.TableEntry, dst []byte) ([]byte, error) {
+	decoder := r.decoder
+	if decoder == nil {
+		var err error
+		if decoder, err = r.archive.decoders.Get(); err != nil {
+			return dst, err
+		}
+		defer r.archive.decoders.Put(decoder)
+	}
+	return decodeEntry(decoder, src, entry, dst)
+}
+
+// reusableFrameBuffer returns the current frame buffer emptied for reuse, or nil if it holds data of the frame
+// cache.
+func (r *ReadSeeker) reusableFrameBuffer() []byte {
+	if r.currentFrameCached {
+		r.currentFrameCached = false
+		return nil
+	}
+	return r.currentFrameBuffer[:0]
+}
+
+// tableError returns the error of reading entries of a lazily read seek table. Entries read after it are zero.
+func (r *ReadSeeker) tableError() error {
+	if err := r.seekTable.Err(); err != nil {
+		return errors.Join(errors.New("failed to read seek table"), err)
+	}
+	return nil
+}
+
+func (r *ReadSeeker) checkFrameIndex(index int) error {
+	if index < 0 || index >= r.seekTable.NumEntries() {
+		return fmt.Errorf("frame index %d out of range [0, %d)", index, r.seekTable.NumEntries())
+	}
+	return nil
+}
+
+func (r *ReadSeeker) frameError(index int, err error) *FrameError {
+	return &FrameError{Frame: r.Frame(index), Err: err}
+}
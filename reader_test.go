@@ -2,9 +2,15 @@ package szstd
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"os"
+	"slices"
 	"testing"
 	"testing/iotest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
 )
 
 func TestReaderIOTEST(t *testing.T) {
@@ -40,3 +46,565 @@ func TestReaderIOTEST(t *testing.T) {
 		t.Fatalf("iotest.TestReader failed: %v", err)
 	}
 }
+
+func TestReaderFrames(t *testing.T) {
+	const frameSize = 4096
+	data := generateTestData(20*frameSize+100, 7)
+	compressed := compressTestData(t, data, frameSize)
+
+	reader, err := NewReadSeeker(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("failed to create szstd reader: %v", err)
+	}
+	defer reader.Close()
+
+	if reader.NumFrames() != 21 {
+		t.Fatalf("expected 21 frames, got %d", reader.NumFrames())
+	}
+	if reader.Size() != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), reader.Size())
+	}
+
+	// Raw frames concatenated form a valid zstd stream
+	var raw []byte
+	for frameData, err := range reader.RawFrames() {
+		if err != nil {
+			t.Fatalf("failed to read raw frame: %v", err)
+		}
+		raw = append(raw, frameData...)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("failed to create zstd decoder: %v", err)
+	}
+	defer decoder.Close()
+	decoded, err := decoder.DecodeAll(raw, nil)
+	if err != nil {
+		t.Fatalf("failed to decode raw frames: %v", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatalf("raw frames do not decode to the original data")
+	}
+
+	// Decoded frames match the original data at the frame offsets
+	var decodedFrames int
+	for frameData, err := range reader.DecodedFrames() {
+		if err != nil {
+			t.Fatalf("failed to decode frame: %v", err)
+		}
+		frame := reader.Frame(decodedFrames)
+		start := frame.EntryOffsetInDecompressed
+		if !bytes.Equal(frameData, data[start:start+uint64(frame.DecompressedSize)]) {
+			t.Fatalf("frame %d does not match original data", decodedFrames)
+		}
+		decodedFrames++
+	}
+	if decodedFrames != reader.NumFrames() {
+		t.Fatalf("decoded %d frames, expected %d", decodedFrames, reader.NumFrames())
+	}
+
+	// Random frame access does not break sequential reading
+	if _, err := reader.Seek(100, io.SeekStart); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	for i, frame := range reader.Frames() {
+		if frame.EntryIndex != i {
+			t.Fatalf("frame %d has index %d", i, frame.EntryIndex)
+		}
+		frameData, err := reader.DecodeFrame(reader.NumFrames()-1-i, []byte("prefix"))
+		if err != nil {
+			t.Fatalf("failed to decode frame: %v", err)
+		}
+		if !bytes.HasPrefix(frameData, []byte("prefix")) {
+			t.Fatalf("DecodeFrame must append to dst")
+		}
+	}
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if !bytes.Equal(rest, data[100:]) {
+		t.Fatalf("data read after frame access does not match")
+	}
+
+	if _, err := reader.RawFrame(reader.NumFrames()); err == nil {
+		t.Fatalf("expected error for out of range frame")
+	}
+}
+
+func TestReaderCorruptFrames(t *testing.T) {
+	const frameSize = 1024
+	data := generateTestData(10*frameSize, 10)
+	compressed := compressTestData(t, data, frameSize)
+
+	reader, err := NewReadSeeker(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("failed to create szstd reader: %v", err)
+	}
+	corrupted := []int{2, 3, 9}
+	for _, index := range corrupted {
+		frame := reader.Frame(index)
+		for i := frame.EntryOffsetInCompressed + 10; i < frame.CompressedRange().End-4; i++ {
+			compressed[i] = 0xFF
+		}
+	}
+	reader.Close()
+
+	t.Run("fail", func(t *testing.T) {
+		reader, err := NewReadSeeker(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("failed to create szstd reader: %v", err)
+		}
+		defer reader.Close()
+
+		read, err := io.ReadAll(reader)
+		var frameErr *FrameError
+		if !errors.As(err, &frameErr) {
+			t.Fatalf("expected FrameError, got %v", err)
+		}
+		if frameErr.EntryIndex != 2 || frameErr.DecompressedRange() != (Range{Start: 2 * frameSize, End: 3 * frameSize}) {
+			t.Fatalf("unexpected frame error: %v", frameErr)
+		}
+		if !bytes.Equal(read, data[:2*frameSize]) {
+			t.Fatalf("data before the corrupted frame does not match")
+		}
+	})
+
+	t.Run("zero fill", func(t *testing.T) {
+		var reported []int
+		reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithCorruptFrameHandler(func(err *FrameError) CorruptFrameAction {
+			reported = append(reported, err.EntryIndex)
+			return CorruptFrameZeroFill
+		}))
+		if err != nil {
+			t.Fatalf("failed to create szstd reader: %v", err)
+		}
+		defer reader.Close()
+
+		expected := slices.Clone(data)
+		for _, index := range corrupted {
+			clear(expected[index*frameSize : (index+1)*frameSize])
+		}
+		if err := iotest.TestReader(reader, expected); err != nil {
+			t.Fatalf("iotest.TestReader failed: %v", err)
+		}
+		slices.Sort(reported)
+		if !slices.Equal(slices.Compact(reported), corrupted) {
+			t.Fatalf("unexpected reported frames %v", reported)
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithCorruptFrameHandler(func(err *FrameError) CorruptFrameAction {
+			return CorruptFrameSkip
+		}))
+		if err != nil {
+			t.Fatalf("failed to create szstd reader: %v", err)
+		}
+		defer reader.Close()
+
+		read, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		expected := slices.Concat(data[:2*frameSize], data[4*frameSize:9*frameSize])
+		if !bytes.Equal(read, expected) {
+			t.Fatalf("read %d bytes, expected %d bytes of intact frames", len(read), len(expected))
+		}
+
+		// Skipped frames keep their offsets
+		if _, err := reader.Seek(3*frameSize-10, io.SeekStart); err != nil {
+			t.Fatalf("failed to seek: %v", err)
+		}
+		buffer := make([]byte, 20)
+		n, err := reader.Read(buffer)
+		if err != nil || !bytes.Equal(buffer[:n], data[4*frameSize:4*frameSize+n]) {
+			t.Fatalf("read after seek into skipped frame returned %d bytes, %v", n, err)
+		}
+	})
+}
+
+func TestReaderSeekEnd(t *testing.T) {
+	data := generateTestData(3*1024+10, 38)
+	reader, err := NewReadSeeker(bytes.NewReader(compressTestData(t, data, 1024)))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	if offset, err := reader.Seek(0, io.SeekEnd); err != nil || offset != int64(len(data)) {
+		t.Fatalf("failed to seek to the end: %d %v", offset, err)
+	}
+	if n, err := reader.Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF at the end, got %d %v", n, err)
+	}
+	if _, err := reader.Seek(-20, io.SeekEnd); err != nil {
+		t.Fatalf("failed to seek back: %v", err)
+	}
+	rest, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(rest, data[len(data)-20:]) {
+		t.Fatalf("unexpected data after seeking back: %v", err)
+	}
+}
+
+func TestReaderCompactIndex(t *testing.T) {
+	data := generateTestData(300*512+77, 39)
+	reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressTestData(t, data, 512)), WithIndexKind(seektable.CompactIndex))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	buffer := make([]byte, 700)
+	for _, offset := range []int64{0, 511, 512, 64 * 512, 200*512 + 3, int64(len(data)) - 700} {
+		if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+			t.Fatalf("failed to seek to %d: %v", offset, err)
+		}
+		if _, err := io.ReadFull(reader, buffer); err != nil {
+			t.Fatalf("failed to read at %d: %v", offset, err)
+		}
+		if !bytes.Equal(buffer, data[offset:offset+700]) {
+			t.Fatalf("data at offset %d does not match", offset)
+		}
+	}
+}
+
+func TestReaderLazySeekTable(t *testing.T) {
+	data := generateTestData(500*256+33, 40)
+	reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressTestData(t, data, 256)), WithLazySeekTable(32, 2))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	buffer := make([]byte, 1000)
+	for _, offset := range []int64{300 * 256, 5, 495*256 - 100, 64*256 + 1} {
+		if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+			t.Fatalf("failed to seek to %d: %v", offset, err)
+		}
+		if _, err := io.ReadFull(reader, buffer); err != nil {
+			t.Fatalf("failed to read at %d: %v", offset, err)
+		}
+		if !bytes.Equal(buffer, data[offset:offset+1000]) {
+			t.Fatalf("data at offset %d does not match", offset)
+		}
+	}
+	if reader.Size() != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), reader.Size())
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("failed to seek to start: %v", err)
+	}
+	all, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(all, data) {
+		t.Fatalf("failed to read all data: %v", err)
+	}
+}
+
+func TestReaderLazySeekTableErrors(t *testing.T) {
+	data := generateTestData(500*256, 41)
+	compressed := compressTestData(t, data, 256)
+	// Entries after the first page of 32 entries cannot be read
+	failFrom, failTo := int64(len(compressed))-int64(8+500*8+9)+8+32*8, int64(len(compressed))-9
+
+	t.Run("reader", func(t *testing.T) {
+		reader, err := NewReadSeekerWithOptions(&failingReaderAt{Reader: bytes.NewReader(compressed), failFrom: failFrom, failTo: failTo}, WithLazySeekTable(32, 2))
+		if err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		if _, err := reader.RawFrame(0); err != nil {
+			t.Fatalf("failed to read frame of the first page: %v", err)
+		}
+		if _, err := reader.RawFrame(100); err == nil {
+			t.Fatalf("read frame with unreadable seek table entry")
+		}
+		if _, err := reader.DecodeFrame(0, nil); err == nil {
+			t.Fatalf("decoded frame after seek table error")
+		}
+		if reader.Size() != -1 {
+			t.Fatalf("expected unknown size, got %d", reader.Size())
+		}
+		if _, err := reader.Seek(-10, io.SeekEnd); err == nil {
+			t.Fatalf("seek relative to the end succeeded after seek table error")
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		archive, err := OpenArchive(&failingReaderAt{Reader: bytes.NewReader(compressed), failFrom: failFrom, failTo: failTo}, WithLazySeekTable(32, 2))
+		if err != nil {
+			t.Fatalf("failed to open archive: %v", err)
+		}
+		defer archive.Close()
+
+		if archive.Size() != -1 {
+			t.Fatalf("expected unknown size, got %d", archive.Size())
+		}
+		if _, err := archive.ReadAt(make([]byte, 10), 0); err == nil || err == io.EOF {
+			t.Fatalf("expected seek table error, got %v", err)
+		}
+	})
+}
+
+// failingReaderAt fails reads of data in [failFrom, failTo).
+type failingReaderAt struct {
+	*bytes.Reader
+	failFrom, failTo int64
+}
+
+func (r *failingReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < r.failTo && offset+int64(len(p)) > r.failFrom {
+		return 0, errors.New("read failed")
+	}
+	return r.Reader.ReadAt(p, offset)
+}
+
+func TestReaderSeekTableParseMode(t *testing.T) {
+	data := generateTestData(1000, 43)
+	compressed := compressTestData(t, data, 256)
+	compressed[len(compressed)-5] |= 0b0000_1000 // reserved bit of the seek table descriptor
+
+	if _, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithSeekTableParseMode(seektable.ParseStrict)); !errors.Is(err, seektable.ErrSeekTableReservedBits) {
+		t.Fatalf("expected reserved bits error, got %v", err)
+	}
+	reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithSeekTableParseMode(seektable.ParseLenient))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(decompressed, data) {
+		t.Fatalf("failed to read data: %v", err)
+	}
+
+	// The head seek table is the only entry allowed without decompressed data
+	head := compressTestData(t, data, 256, WithChecksums(true), WithHeadSeekTable(t.TempDir()))
+	strict, err := NewReadSeekerWithOptions(bytes.NewReader(head), WithSeekTableParseMode(seektable.ParseStrict))
+	if err != nil {
+		t.Fatalf("failed to parse head seek table strictly: %v", err)
+	}
+	strict.Close()
+}
+
+func TestReaderLargeFrames(t *testing.T) {
+	data := generateTestData(3*streamWindowSize+100, 47)
+	compressed := compressTestData(t, data, 2*streamWindowSize+7, WithChecksums(true))
+	reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithStreamingThreshold(1000))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if err := iotest.TestReader(reader, data); err != nil {
+		t.Fatalf("iotest failed: %v", err)
+	}
+
+	buffer := make([]byte, 10)
+	for _, offset := range []int64{2*streamWindowSize - 5, 5, streamWindowSize + 3, 3*streamWindowSize + 90} {
+		if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+			t.Fatalf("failed to seek to %d: %v", offset, err)
+		}
+		if _, err := io.ReadFull(reader, buffer); err != nil || !bytes.Equal(buffer, data[offset:offset+10]) {
+			t.Fatalf("failed to read at %d: %v", offset, err)
+		}
+		if cap(reader.currentFrameBuffer) > streamWindowSize {
+			t.Fatalf("frame buffer of %d bytes exceeds the window", cap(reader.currentFrameBuffer))
+		}
+	}
+
+	// Damaged large frame fails when its damaged part is decoded
+	damaged := bytes.Clone(compressed)
+	frame := reader.Frame(0).CompressedRange()
+	clear(damaged[frame.End-1000 : frame.End])
+	reader, err = NewReadSeekerWithOptions(bytes.NewReader(damaged), WithStreamingThreshold(1000))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	var frameErr *FrameError
+	if _, err := io.ReadAll(reader); !errors.As(err, &frameErr) || frameErr.EntryIndex != 0 {
+		t.Fatalf("expected error of frame 0, got %v", err)
+	}
+}
+
+func TestReaderMaxFrameSize(t *testing.T) {
+	var frameErr *FrameError
+	// Seek table declaring a frame of 4 GiB for 100 bytes of data
+	data := generateTestData(100, 48)
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	frame := encoder.EncodeAll(data, nil)
+	table, err := seektable.NewTableFromEntries([]seektable.TableEntry{{CompressedSize: uint32(len(frame)), DecompressedSize: 1<<32 - 1}}, false).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal seek table: %v", err)
+	}
+	hostile := append(frame, table...)
+
+	reader, err := NewReadSeeker(bytes.NewReader(hostile))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrFrameSizeMismatch) {
+		t.Fatalf("expected frame size mismatch, got %v", err)
+	}
+
+	reader, err = NewReadSeekerWithOptions(bytes.NewReader(hostile), WithMaxFrameSize(1<<20))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrFrameSizeLimit) {
+		t.Fatalf("expected frame size limit error, got %v", err)
+	}
+	if _, err := reader.DecodeFrame(0, nil); !errors.Is(err, ErrFrameSizeLimit) {
+		t.Fatalf("expected frame size limit error, got %v", err)
+	}
+
+	// Rejected frames are replaced with zeros in windows
+	reader, err = NewReadSeekerWithOptions(bytes.NewReader(hostile), WithMaxFrameSize(1<<20),
+		WithCorruptFrameHandler(func(err *FrameError) CorruptFrameAction { return CorruptFrameZeroFill }))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if _, err := reader.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	if end, err := io.ReadAll(reader); err != nil || !bytes.Equal(end, make([]byte, 10)) {
+		t.Fatalf("expected zeros at the end, got %v", err)
+	}
+
+	// Limit applies to frames decoded at once as well
+	reader, err = NewReadSeekerWithOptions(bytes.NewReader(compressTestData(t, generateTestData(5000, 49), 2000)), WithMaxFrameSize(1000))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrFrameSizeLimit) || !errors.As(err, &frameErr) {
+		t.Fatalf("expected frame size limit error, got %v", err)
+	}
+}
+
+func TestReaderDecompressionLimits(t *testing.T) {
+	// Archive of a single frame with a forged seek table entry
+	data := generateTestData(100*1000, 49)
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	frame := encoder.EncodeAll(data, nil)
+	forged := func(decompressedSize uint32) []byte {
+		table, err := seektable.NewTableFromEntries([]seektable.TableEntry{{CompressedSize: uint32(len(frame)), DecompressedSize: decompressedSize}}, false).MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to marshal seek table: %v", err)
+		}
+		return slices.Concat(frame, table)
+	}
+	for _, declared := range []uint32{1000, uint32(len(data)) + 1} {
+		reader, err := NewReadSeeker(bytes.NewReader(forged(declared)))
+		if err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+		defer reader.Close()
+		if _, err := io.ReadAll(reader); !errors.Is(err, ErrFrameSizeMismatch) {
+			t.Fatalf("declared %d: expected frame size mismatch, got %v", declared, err)
+		}
+		if decoded, err := reader.DecodeFrame(0, nil); !errors.Is(err, ErrFrameSizeMismatch) || len(decoded) != 0 {
+			t.Fatalf("declared %d: expected frame size mismatch, got %d bytes, %v", declared, len(decoded), err)
+		}
+	}
+
+	// Total decompressed size
+	data = generateTestData(5000, 50)
+	compressed := compressTestData(t, data, 1000)
+	if _, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithMaxDecompressedSize(4000)); !errors.Is(err, ErrDecompressedSizeLimit) {
+		t.Fatalf("expected decompressed size limit error, got %v", err)
+	}
+	reader, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithMaxDecompressedSize(4000), WithLazySeekTable(0, 0))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if decompressed, err := io.ReadAll(reader); !errors.Is(err, ErrDecompressedSizeLimit) || !bytes.Equal(decompressed, data[:4000]) {
+		t.Fatalf("expected decompressed size limit error after 4000 bytes, got %d bytes, %v", len(decompressed), err)
+	}
+	stream, err := NewReader(streamOnly{bytes.NewReader(compressed)}, WithMaxDecompressedSize(4000))
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer stream.Close()
+	if decompressed, err := io.ReadAll(stream); !errors.Is(err, ErrDecompressedSizeLimit) || !bytes.Equal(decompressed, data[:4000]) {
+		t.Fatalf("expected decompressed size limit error after 4000 bytes, got %d bytes, %v", len(decompressed), err)
+	}
+	headArchive := compressTestData(t, data, 1000, WithHeadSeekTable(t.TempDir()))
+	if _, err := NewReader(bytes.NewReader(headArchive), WithMaxDecompressedSize(4000)); !errors.Is(err, ErrDecompressedSizeLimit) {
+		t.Fatalf("expected decompressed size limit error, got %v", err)
+	}
+
+	// Compression ratio of zeros
+	compressed = compressTestData(t, make([]byte, 5000), 1000)
+	reader, err = NewReadSeekerWithOptions(bytes.NewReader(compressed), WithMaxCompressionRatio(10))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	var frameErr *FrameError
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrCompressionRatioLimit) || !errors.As(err, &frameErr) || frameErr.EntryIndex != 0 {
+		t.Fatalf("expected compression ratio limit error of frame 0, got %v", err)
+	}
+	if _, err := NewReadSeekerWithOptions(bytes.NewReader(compressed), WithMaxCompressionRatio(0.5)); err == nil {
+		t.Fatalf("expected error for compression ratio below 1")
+	}
+}
+
+func TestReaderDecoderOptions(t *testing.T) {
+	const frameSize = 1000
+	data := generateTestData(10*frameSize, 53)
+	dict := generateTestData(4096, 54)
+
+	compressed := &bytes.Buffer{}
+	writer, err := NewWriter(compressed, frameSize, zstd.WithEncoderDictRaw(7, dict))
+	if err != nil {
+		t.Fatalf("failed to create szstd writer: %v", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("failed to write data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close szstd writer: %v", err)
+	}
+
+	// Decoder options are accepted directly and through WithDecoderOptions
+	for name, open := range map[string]func() (*ReadSeeker, error){
+		"NewReadSeeker": func() (*ReadSeeker, error) {
+			return NewReadSeeker(bytes.NewReader(compressed.Bytes()), zstd.WithDecoderDictRaw(7, dict))
+		},
+		"NewReadSeekerWithOptions": func() (*ReadSeeker, error) {
+			return NewReadSeekerWithOptions(bytes.NewReader(compressed.Bytes()), WithDecoderOptions(zstd.WithDecoderDictRaw(7, dict)))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reader, err := open()
+			if err != nil {
+				t.Fatalf("failed to create szstd reader: %v", err)
+			}
+			defer reader.Close()
+			decompressed, err := io.ReadAll(reader)
+			if err != nil || !bytes.Equal(decompressed, data) {
+				t.Fatalf("decompressed data does not match (%v)", err)
+			}
+		})
+	}
+
+	reader, err := NewReadSeeker(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create szstd reader: %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Fatalf("frames compressed with a dictionary decoded without it")
+	}
+}
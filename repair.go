@@ -0,0 +1,272 @@
+package szstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// RepairReport is the result of `Repair`.
+type RepairReport struct {
+	// Frames were located through the seek table of the damaged archive. Otherwise the seek table was
+	// unreadable or inconsistent, and frames were found by scanning the archive from the beginning.
+	UsedSeekTable bool
+
+	KeptFrames       int
+	CompressedSize   uint64 // size of frames written to the repaired archive
+	DecompressedSize uint64
+
+	Lost []LostData // in archive order
+}
+
+// LostData describes a part of the damaged archive that was not copied into the repaired archive.
+type LostData struct {
+	Compressed Range // in the damaged archive
+
+	// Range of the damaged archive decompressed stream. Only valid if DecompressedKnown is set. It is unknown when
+	// frames were found by scanning and the lost bytes are not a frame with content size in its header, and for all
+	// data that follows such bytes.
+	Decompressed      Range
+	DecompressedKnown bool
+
+	Err error
+}
+
+// Repair copies every frame of the damaged src archive that decodes correctly into dst and writes a new seek table
+// describing them. Healthy frames are copied verbatim, without re-encoding. Frames are located with the seek table
+// of src when it is readable and consistent with the data, including the sizes of frames found at its offsets.
+// Otherwise src is scanned from the beginning, frame by frame, and unparsable bytes are skipped until the next frame
+// magic number.
+// Skippable frames of src, including trailers written by this package, are not copied.
+// Frames exceeding the limits of the reader options are reported as lost, without decoding them when their size is
+// declared by the seek table or their header.
+// Error is returned only if src or dst fail; damage is described by the report.
+func Repair(dst io.Writer, src io.ReadSeeker, opts ...ReaderOption) (*RepairReport, error) {
+	o, err := applyReaderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := newTableDecoder(o)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	fileSize, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to seek to end of data"), err)
+	}
+
+	r := &repairer{src: src, dst: dst, decoder: decoder, options: o, report: &RepairReport{}}
+	defer func() {
+		if r.unsizedDecoder != nil {
+			r.unsizedDecoder.Close()
+		}
+	}()
+	useTable := false
+	table, err := seektable.ReadTableFromReadSeeker(src, seektable.WithParseMode(o.parseMode))
+	if err == nil && framesEnd(table) <= uint64(fileSize)-uint64(table.Size()) {
+		if useTable, err = tableMatchesFrames(src, table); err != nil {
+			return nil, err
+		}
+	}
+	if useTable {
+		r.report.UsedSeekTable = true
+		r.table = seektable.NewTable(table.HasChecksums())
+		err = r.repairWithTable(table)
+	} else {
+		r.table = seektable.NewTable(false)
+		err = r.repairByScanning(fileSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := seektable.WriteTableToWriter(r.table, dst); err != nil {
+		return nil, errors.Join(errors.New("failed to write seek table"), err)
+	}
+	return r.report, nil
+}
+
+// tableMatchesFrames tells whether the frames found at the offsets of the seek table entries have the compressed
+// sizes of the entries. A single corrupted size would misplace all following frames. Frames that cannot be parsed
+// are ignored, since they may be damaged themselves.
+func tableMatchesFrames(src io.ReadSeeker, table *seektable.Table) (bool, error) {
+	dataEnd := int64(framesEnd(table))
+	for i := 0; i < table.NumEntries(); i++ {
+		offset := int64(table.OffsetsByIndex(i).EntryOffsetInCompressed)
+		frame, err := scanFrame(src, offset, dataEnd)
+		if errors.Is(err, ErrInvalidFrame) {
+			continue
+		}
+		if err != nil {
+			return false, errors.Join(fmt.Errorf("failed to read frame %d", i), err)
+		}
+		if frame.size != int64(table.GetEntry(i).CompressedSize) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type repairer struct {
+	src     io.ReadSeeker
+	dst     io.Writer
+	decoder *zstd.Decoder // decoder of frames of known size, created by `newTableDecoder`
+	options readerOptions
+	table   *seektable.Table
+	report  *RepairReport
+
+	unsizedDecoder *zstd.Decoder // decoder of frames without declared size, created when first needed
+
+	raw     []byte
+	decoded []byte
+}
+
+func (r *repairer) repairWithTable(table *seektable.Table) error {
+	for i := 0; i < table.NumEntries(); i++ {
+		frame := Frame{TableOffset: table.OffsetsByIndex(i), TableEntry: table.GetEntry(i)}
+		lose := func(err error) {
+			r.report.Lost = append(r.report.Lost, LostData{
+				Compressed:        frame.CompressedRange(),
+				Decompressed:      frame.DecompressedRange(),
+				DecompressedKnown: true,
+				Err:               err,
+			})
+		}
+		if err := r.options.checkFrame(frame); err != nil {
+			lose(err)
+			continue
+		}
+
+		var err error
+		r.raw, err = readAt(r.src, frame.EntryOffsetInCompressed, int(frame.CompressedSize), r.raw)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to read frame %d", i), err)
+		}
+		if frame.DecompressedSize == 0 && len(r.raw) >= 4 && binary.LittleEndian.Uint32(r.raw)&skippableMagicMask == skippableMagicBase {
+			continue // such as the head seek table, which would not describe the repaired archive
+		}
+		r.decoded, err = decodeEntry(r.decoder, r.raw, frame.TableEntry, r.decoded[:0])
+		if err == nil {
+			err = checkDecodedFrame(frame, r.decoded, table.HasChecksums())
+		}
+		if err != nil {
+			lose(err)
+			continue
+		}
+
+		if err := r.keep(frame.TableEntry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repairer) repairByScanning(fileSize int64) error {
+	var position int64
+	var decompressedOffset uint64
+	offsetsKnown := true
+	lose := func(compressed Range, decompressedSize uint64, sizeKnown bool, err error) {
+		offsetsKnown = offsetsKnown && sizeKnown
+		lost := LostData{Compressed: compressed, DecompressedKnown: offsetsKnown, Err: err}
+		if offsetsKnown {
+			lost.Decompressed = Range{Start: decompressedOffset, End: decompressedOffset + decompressedSize}
+			decompressedOffset += decompressedSize
+		}
+		r.report.Lost = append(r.report.Lost, lost)
+	}
+
+	for position < fileSize {
+		frame, err := scanFrame(r.src, position, fileSize)
+		if err != nil {
+			next, err := findFrameStart(r.src, position+1, fileSize)
+			if err != nil {
+				return errors.Join(errors.New("failed to search for the next frame"), err)
+			}
+			lose(Range{Start: uint64(position), End: uint64(next)}, 0, false, ErrInvalidFrame)
+			position = next
+			continue
+		}
+		compressed := Range{Start: uint64(position), End: uint64(position + frame.size)}
+		position += frame.size
+		if frame.skippable {
+			continue
+		}
+
+		frameErr, err := r.decodeScanned(frame, compressed, decompressedOffset)
+		if err != nil {
+			return err
+		}
+		if frameErr != nil {
+			lose(compressed, frame.contentSize, frame.hasContentSize, frameErr)
+			continue
+		}
+
+		if err := r.keep(seektable.TableEntry{CompressedSize: uint32(frame.size), DecompressedSize: uint32(len(r.decoded))}); err != nil {
+			return err
+		}
+		decompressedOffset += uint64(len(r.decoded))
+	}
+	return nil
+}
+
+// decodeScanned reads and decodes the frame found by scanning at the compressed range into r.raw and r.decoded.
+// The limits of the reader options are checked before decoding if the frame header declares its size, and after
+// decoding otherwise. Damage of the frame is returned as frameErr, failures of the source as err.
+func (r *repairer) decodeScanned(scanned scannedFrame, compressed Range, decompressedOffset uint64) (frameErr, err error) {
+	if scanned.size > math.MaxUint32 || scanned.contentSize > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes compressed, %d bytes declared", ErrFrameTooLarge, scanned.size, scanned.contentSize), nil
+	}
+	frame := Frame{
+		TableOffset: seektable.TableOffset{EntryOffsetInCompressed: compressed.Start, EntryOffsetInDecompressed: decompressedOffset},
+		TableEntry:  seektable.TableEntry{CompressedSize: uint32(scanned.size), DecompressedSize: uint32(scanned.contentSize)},
+	}
+	if scanned.hasContentSize {
+		if err := r.options.checkFrame(frame); err != nil {
+			return err, nil
+		}
+	}
+
+	r.raw, err = readAt(r.src, compressed.Start, int(scanned.size), r.raw)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to read frame at offset %d", compressed.Start), err)
+	}
+	if scanned.hasContentSize {
+		r.decoded, frameErr = decodeEntry(r.decoder, r.raw, frame.TableEntry, r.decoded[:0])
+		return frameErr, nil
+	}
+	if r.unsizedDecoder == nil {
+		if r.unsizedDecoder, err = newDecoder(r.options); err != nil {
+			return nil, err
+		}
+	}
+	if r.decoded, err = r.unsizedDecoder.DecodeAll(r.raw, r.decoded[:0]); err != nil {
+		return errors.Join(errors.New("failed to decode frame"), err), nil
+	}
+	if int64(len(r.decoded)) > math.MaxUint32 {
+		return fmt.Errorf("%w: frame decompressed to %d bytes", ErrFrameTooLarge, len(r.decoded)), nil
+	}
+	frame.DecompressedSize = uint32(len(r.decoded))
+	return r.options.checkFrame(frame), nil
+}
+
+// keep writes the last read frame to the repaired archive.
+func (r *repairer) keep(entry seektable.TableEntry) error {
+	if r.table.NumEntries() >= seektable.MaxEntries(r.table.HasChecksums()) {
+		return fmt.Errorf("%w: at most %d frames", ErrTooManyFrames, r.table.NumEntries())
+	}
+	if _, err := r.dst.Write(r.raw); err != nil {
+		return errors.Join(errors.New("failed to write frame"), err)
+	}
+	r.table.AppendEntry(entry)
+	r.report.KeptFrames++
+	r.report.CompressedSize += uint64(entry.CompressedSize)
+	r.report.DecompressedSize += uint64(entry.DecompressedSize)
+	return nil
+}
@@ -0,0 +1,210 @@
+package szstd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+func TestRepair(t *testing.T) {
+	const frameSize = 8 * 1024
+	data := generateTestData(16*frameSize, 12)
+	healthy := compressTestData(t, data, frameSize, WithChecksums(true))
+	table, err := seektable.ReadTableFromReadSeeker(bytes.NewReader(healthy))
+	if err != nil {
+		t.Fatalf("failed to read seek table: %v", err)
+	}
+	frameStart := func(index int) int {
+		return int(table.OffsetsByIndex(index).EntryOffsetInCompressed)
+	}
+
+	repair := func(archive []byte) ([]byte, *RepairReport) {
+		repaired := bytes.NewBuffer(nil)
+		report, err := Repair(repaired, bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("failed to repair archive: %v", err)
+		}
+		verifyReport, err := Verify(bytes.NewReader(repaired.Bytes()), 2)
+		if err != nil {
+			t.Fatalf("failed to verify repaired archive: %v", err)
+		}
+		if !verifyReport.OK() {
+			t.Fatalf("repaired archive has problems: %+v", verifyReport)
+		}
+		reader, err := NewReadSeeker(bytes.NewReader(repaired.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open repaired archive: %v", err)
+		}
+		defer reader.Close()
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read repaired archive: %v", err)
+		}
+		return decompressed, report
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		decompressed, report := repair(healthy)
+		if !bytes.Equal(decompressed, data) {
+			t.Fatalf("repaired data does not match")
+		}
+		if !report.UsedSeekTable || report.KeptFrames != 16 || len(report.Lost) != 0 {
+			t.Fatalf("unexpected report for healthy archive: %+v", report)
+		}
+	})
+
+	t.Run("corrupted frames", func(t *testing.T) {
+		archive := slices.Clone(healthy)
+		for _, index := range []int{3, 7} {
+			for i := range 32 {
+				archive[frameStart(index)+20+i] ^= 0x55
+			}
+		}
+
+		decompressed, report := repair(archive)
+		if !report.UsedSeekTable || report.KeptFrames != 14 || len(report.Lost) != 2 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		var expected []byte
+		for i, chunk := range slices.Collect(slices.Chunk(data, frameSize)) {
+			if i != 3 && i != 7 {
+				expected = append(expected, chunk...)
+			}
+		}
+		if !bytes.Equal(decompressed, expected) {
+			t.Fatalf("repaired data does not match")
+		}
+		lost := report.Lost[1]
+		if !lost.DecompressedKnown || lost.Decompressed != (Range{Start: 7 * frameSize, End: 8 * frameSize}) {
+			t.Fatalf("unexpected decompressed range of lost frame: %+v", lost)
+		}
+		if lost.Compressed != (Range{Start: uint64(frameStart(7)), End: uint64(frameStart(8))}) {
+			t.Fatalf("unexpected compressed range of lost frame: %+v", lost)
+		}
+	})
+
+	t.Run("head seek table", func(t *testing.T) {
+		archive := compressTestData(t, data, frameSize, WithChecksums(true), WithHeadSeekTable(t.TempDir()))
+		table, err := seektable.ReadTableFromReadSeeker(bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("failed to read seek table: %v", err)
+		}
+		archive[table.OffsetsByIndex(5).EntryOffsetInCompressed+20] ^= 0x55 // frame 4, entry 0 is the head seek table
+
+		repaired := bytes.NewBuffer(nil)
+		report, err := Repair(repaired, bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("failed to repair archive: %v", err)
+		}
+		if !report.UsedSeekTable || report.KeptFrames != 15 || len(report.Lost) != 1 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		// The stale head seek table is dropped, so sequential readers do not check frames against it
+		reader, err := NewReader(bytes.NewReader(repaired.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open repaired archive: %v", err)
+		}
+		defer reader.Close()
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read repaired archive: %v", err)
+		}
+		if !bytes.Equal(decompressed, slices.Concat(data[:4*frameSize], data[5*frameSize:])) {
+			t.Fatalf("repaired data does not match")
+		}
+	})
+
+	t.Run("corrupted seek table entry", func(t *testing.T) {
+		archive := slices.Clone(healthy)
+		entry := len(archive) - int(table.Size()) + 8 + 5*12 // compressed size of entry 5, after the frame header
+		archive[entry] -= 10
+
+		decompressed, report := repair(archive)
+		if report.UsedSeekTable || report.KeptFrames != 16 || len(report.Lost) != 0 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if !bytes.Equal(decompressed, data) {
+			t.Fatalf("repaired data does not match")
+		}
+	})
+
+	t.Run("broken seek table", func(t *testing.T) {
+		garbage := bytes.Repeat([]byte{0xAA}, 100)
+		archive := slices.Concat(healthy[:frameStart(10)], garbage, healthy[frameStart(10):])
+		archive[frameStart(6)-1] ^= 0xFF // zstd checksum of frame 5
+		archive[len(archive)-1] ^= 0xFF  // seek table footer magic number
+
+		decompressed, report := repair(archive)
+		if report.UsedSeekTable || report.KeptFrames != 15 || len(report.Lost) != 2 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		var expected []byte
+		for i, chunk := range slices.Collect(slices.Chunk(data, frameSize)) {
+			if i != 5 {
+				expected = append(expected, chunk...)
+			}
+		}
+		if !bytes.Equal(decompressed, expected) {
+			t.Fatalf("repaired data does not match")
+		}
+
+		corrupted := report.Lost[0]
+		if !corrupted.DecompressedKnown || corrupted.Decompressed != (Range{Start: 5 * frameSize, End: 6 * frameSize}) {
+			t.Fatalf("unexpected decompressed range of corrupted frame: %+v", corrupted)
+		}
+		skipped := report.Lost[1]
+		if skipped.Compressed != (Range{Start: uint64(frameStart(10)), End: uint64(frameStart(10) + len(garbage))}) || skipped.DecompressedKnown {
+			t.Fatalf("unexpected range of garbage: %+v", skipped)
+		}
+	})
+}
+
+func TestRepairLimits(t *testing.T) {
+	repairBomb := func(archive []byte, opts ...ReaderOption) *RepairReport {
+		t.Helper()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		report, err := Repair(io.Discard, bytes.NewReader(archive), opts...)
+		if err != nil {
+			t.Fatalf("failed to repair archive: %v", err)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16*1024*1024 {
+			t.Fatalf("repair allocated %d bytes for a frame of 10 bytes", allocated)
+		}
+		if report.KeptFrames != 0 || len(report.Lost) != 1 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		return report
+	}
+
+	t.Run("seek table", func(t *testing.T) {
+		report := repairBomb(compressionBombArchive(t, 4096)) // 16 KiB decompressing to 512 MiB
+		if !report.UsedSeekTable || !errors.Is(report.Lost[0].Err, ErrFrameSizeMismatch) {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("scanning", func(t *testing.T) {
+		report := repairBomb(compressionBomb(4096), WithMaxFrameSize(1024*1024))
+		if report.UsedSeekTable || report.Lost[0].Err == nil {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("reader options", func(t *testing.T) {
+		archive := compressTestData(t, generateTestData(4*4096, 54), 4096)
+		report, err := Repair(io.Discard, bytes.NewReader(archive), WithMaxCompressionRatio(1.5))
+		if err != nil {
+			t.Fatalf("failed to repair archive: %v", err)
+		}
+		if report.KeptFrames != 0 || len(report.Lost) != 4 || !errors.Is(report.Lost[0].Err, ErrCompressionRatioLimit) {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+}
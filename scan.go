@@ -0,0 +1,229 @@
+package szstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+const zstdMagicNumber uint32 = 0xFD2FB528
+
+const maxBlockSize = 128 * 1024
+
+// maxSeekTableFrameSize is the size of the largest valid seek table frame, including its headers.
+var maxSeekTableFrameSize = int64(max(seektable.MaxEntries(false)*8, seektable.MaxEntries(true)*12)) + 17
+
+// readChunkSize bounds the memory allocated ahead of the data actually read from a stream.
+const readChunkSize = 64 * 1024
+
+// scannedFrame is a frame located by parsing its headers.
+type scannedFrame struct {
+	size           int64 // total size of the frame, including headers and checksum
+	skippable      bool
+	contentSize    uint64 // decompressed size from the frame header, only valid if hasContentSize
+	hasContentSize bool
+}
+
+// scanFrame parses the frame starting at the offset without decoding it: the frame header and then every block
+// header until the last block. The whole frame must end at or before limit.
+func scanFrame(r io.ReadSeeker, offset, limit int64) (scannedFrame, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return scannedFrame{}, err
+	}
+	var headerBuffer [zstd.HeaderMaxSize]byte
+	headerData := headerBuffer[:min(int64(len(headerBuffer)), limit-offset)]
+	if _, err := io.ReadFull(r, headerData); err != nil {
+		return scannedFrame{}, err
+	}
+	var header zstd.Header
+	if err := header.Decode(headerData); err != nil {
+		return scannedFrame{}, errors.Join(ErrInvalidFrame, err)
+	}
+
+	if header.Skippable {
+		frame := scannedFrame{size: int64(header.HeaderSize) + int64(header.SkippableSize), skippable: true}
+		if offset+frame.size > limit {
+			return scannedFrame{}, errors.Join(ErrInvalidFrame, errors.New("skippable frame is truncated"))
+		}
+		return frame, nil
+	}
+
+	frame := scannedFrame{contentSize: header.FrameContentSize, hasContentSize: header.HasFCS}
+	position := offset + int64(header.HeaderSize)
+	for {
+		if position+3 > limit {
+			return scannedFrame{}, errors.Join(ErrInvalidFrame, errors.New("frame is truncated"))
+		}
+		if _, err := r.Seek(position, io.SeekStart); err != nil {
+			return scannedFrame{}, err
+		}
+		var blockHeader [4]byte
+		if _, err := io.ReadFull(r, blockHeader[:3]); err != nil {
+			return scannedFrame{}, err
+		}
+		value := binary.LittleEndian.Uint32(blockHeader[:])
+		last := value&1 == 1
+		blockSize := int64(value >> 3)
+		switch blockType := (value >> 1) & 3; blockType {
+		case 1: // RLE block stores a single byte
+			blockSize = 1
+		case 3:
+			return scannedFrame{}, errors.Join(ErrInvalidFrame, fmt.Errorf("reserved block type at offset %d", position))
+		}
+		if blockSize > maxBlockSize {
+			return scannedFrame{}, errors.Join(ErrInvalidFrame, fmt.Errorf("block at offset %d is too large", position))
+		}
+		position += 3 + blockSize
+		if last {
+			break
+		}
+	}
+	if header.HasCheckSum {
+		position += 4
+	}
+	if position > limit {
+		return scannedFrame{}, errors.Join(ErrInvalidFrame, errors.New("frame is truncated"))
+	}
+
+	frame.size = position - offset
+	return frame, nil
+}
+
+// findFrameStart returns the offset of the first zstd or skippable frame magic number in [offset, limit),
+// or limit if there is none.
+func findFrameStart(r io.ReadSeeker, offset, limit int64) (int64, error) {
+	buffer := make([]byte, 64*1024)
+	for offset+4 <= limit {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		chunk := buffer[:min(int64(len(buffer)), limit-offset)]
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return 0, err
+		}
+		for i := 0; i+4 <= len(chunk); i++ {
+			magic := binary.LittleEndian.Uint32(chunk[i:])
+			if magic == zstdMagicNumber || magic&skippableMagicMask == skippableMagicBase {
+				return offset + int64(i), nil
+			}
+		}
+		offset += int64(len(chunk)) - 3 // magic number can span chunks
+	}
+	return limit, nil
+}
+
+// readFrame reads the next complete frame from r, appending it to dst. Returns io.EOF if r ends before the frame.
+// The frame is located by parsing its headers as in `scanFrame`, without decoding it.
+//
+// Only the headers of skippable frames are appended, except for seek tables. Reading fails with ErrFrameSizeLimit
+// as soon as a seek table or other frame exceeds limit, before it is buffered. A limit of 0 means no limit.
+func readFrame(r io.Reader, dst []byte, limit int64) ([]byte, scannedFrame, error) {
+	start := len(dst)
+	read := func(n int) error {
+		dst = slices.Grow(dst, n)
+		_, err := io.ReadFull(r, dst[len(dst):len(dst)+n])
+		dst = dst[:len(dst)+n]
+		return err
+	}
+
+	if err := read(5); err != nil {
+		return dst, scannedFrame{}, err // io.EOF only if nothing was read
+	}
+	magic := binary.LittleEndian.Uint32(dst[start:])
+	var headerSize int
+	switch {
+	case magic&skippableMagicMask == skippableMagicBase:
+		headerSize = 8
+	case magic == zstdMagicNumber:
+		descriptor := dst[start+4]
+		singleSegment := descriptor>>5&1 == 1
+		headerSize = 5 + []int{0, 1, 2, 4}[descriptor&3] // dictionary ID
+		if !singleSegment {
+			headerSize++ // window descriptor
+		}
+		switch contentSizeFlag := descriptor >> 6; {
+		case contentSizeFlag == 0 && singleSegment:
+			headerSize += 1
+		case contentSizeFlag > 0:
+			headerSize += 1 << contentSizeFlag
+		}
+	default:
+		return dst, scannedFrame{}, errors.Join(ErrInvalidFrame, fmt.Errorf("unknown magic number %08x", magic))
+	}
+	if err := read(headerSize - 5); err != nil {
+		return dst, scannedFrame{}, noEOF(err)
+	}
+	var header zstd.Header
+	if err := header.Decode(dst[start:]); err != nil {
+		return dst, scannedFrame{}, errors.Join(ErrInvalidFrame, err)
+	}
+
+	if header.Skippable {
+		size := int64(headerSize) + int64(header.SkippableSize)
+		if magic != seekTableMagicNumber {
+			// Other skippable frames are not needed, so their declared size is not allocated
+			if _, err := io.CopyN(io.Discard, r, int64(header.SkippableSize)); err != nil {
+				return dst, scannedFrame{}, noEOF(err)
+			}
+			return dst, scannedFrame{size: size, skippable: true}, nil
+		}
+		if size > maxSeekTableFrameSize {
+			return dst, scannedFrame{}, errors.Join(ErrInvalidFrame, fmt.Errorf("seek table of %d bytes is too large", size))
+		}
+		if limit > 0 && size > limit {
+			return dst, scannedFrame{}, fmt.Errorf("%w: seek table has %d bytes, limit is %d", ErrFrameSizeLimit, size, limit)
+		}
+		// The declared size is not trusted before the data arrives
+		for remaining := int(header.SkippableSize); remaining > 0; remaining -= readChunkSize {
+			if err := read(min(remaining, readChunkSize)); err != nil {
+				return dst, scannedFrame{}, noEOF(err)
+			}
+		}
+		return dst, scannedFrame{size: size, skippable: true}, nil
+	}
+
+	for {
+		if err := read(3); err != nil {
+			return dst, scannedFrame{}, noEOF(err)
+		}
+		value := uint32(dst[len(dst)-3]) | uint32(dst[len(dst)-2])<<8 | uint32(dst[len(dst)-1])<<16
+		blockSize := int(value >> 3)
+		switch blockType := (value >> 1) & 3; blockType {
+		case 1: // RLE block stores a single byte
+			blockSize = 1
+		case 3:
+			return dst, scannedFrame{}, errors.Join(ErrInvalidFrame, errors.New("reserved block type"))
+		}
+		if blockSize > maxBlockSize {
+			return dst, scannedFrame{}, errors.Join(ErrInvalidFrame, errors.New("block is too large"))
+		}
+		if size := int64(len(dst) - start + blockSize); limit > 0 && size > limit {
+			return dst, scannedFrame{}, fmt.Errorf("%w: frame has more than %d bytes, limit is %d", ErrFrameSizeLimit, size-1, limit)
+		}
+		if err := read(blockSize); err != nil {
+			return dst, scannedFrame{}, noEOF(err)
+		}
+		if value&1 == 1 {
+			break
+		}
+	}
+	if header.HasCheckSum {
+		if err := read(4); err != nil {
+			return dst, scannedFrame{}, noEOF(err)
+		}
+	}
+	return dst, scannedFrame{size: int64(len(dst) - start), contentSize: header.FrameContentSize, hasContentSize: header.HasFCS}, nil
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF, for reads that must not end the stream.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -1,22 +1,36 @@
 package seektable
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"sync"
 )
 
 const headerMagicNumber uint32 = 0x184D2A5E
 const footerMagicNumber uint32 = 0x8F92EAB1
 
+const checksumFlag byte = 1 << 7 // bit of the seek table descriptor
+
 var ErrInvalidSeekTable = errors.New("invalid seek table")
 var ErrInvalidSeekTableFooterMagicNumber = errors.New("invalid seek table footer magic number")
 var ErrInvalidSeekTableHeaderMagicNumber = errors.New("invalid seek table header magic number")
 var ErrSeekTableSizeMismatch = errors.New("seek table size mismatch")
+var ErrTooManyEntries = errors.New("seek table has more entries than its format allows")
+
+// ReadTableFromReadSeeker reads the seek table located at the end of data. See `WithParseMode` for checks of the table.
+func ReadTableFromReadSeeker(data io.ReadSeeker, opts ...ParseOption) (*Table, error) {
+	o, err := applyParseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 
-func ReadTableFromReadSeeker(data io.ReadSeeker) (*Table, error) {
 	// Get last 9 bytes to read footer
-	_, err := data.Seek(-9, io.SeekEnd)
+	footerOffset, err := data.Seek(-9, io.SeekEnd)
 	if err != nil {
 		return nil, errors.Join(errors.New("error while seeking to seek table footer"), err)
 	}
@@ -25,14 +39,24 @@ func ReadTableFromReadSeeker(data io.ReadSeeker) (*Table, error) {
 	if err != nil {
 		return nil, errors.Join(errors.New("error while reading seek table footer"), err)
 	}
-
-	numEntries := binary.LittleEndian.Uint32(footer[0:4])
-	if binary.LittleEndian.Uint32(footer[5:9]) != footerMagicNumber {
-		return nil, errors.Join(ErrInvalidSeekTable, ErrInvalidSeekTableFooterMagicNumber)
+	numEntries, checksums, err := o.checkFooter(footer[:])
+	if err != nil {
+		return nil, err
+	}
+	entrySize := int64(8)
+	if checksums {
+		entrySize = 12
 	}
 
-	// Seek to the beginning of the seek table. 8 bytes header + (entries * 8 bytes each) + 9 bytes footer
-	seekTableSize := int64(8 + (numEntries * 8) + 9)
+	// Seek to the beginning of the seek table. 8 bytes header + (entries * entry size) + 9 bytes footer
+	entriesSize := numEntries * entrySize // at most 2^32 * 12, computed in 64 bits
+	seekTableSize := 8 + entriesSize + 9
+	if seekTableSize > footerOffset+9 {
+		return nil, errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeMismatch)
+	}
+	if entriesSize > math.MaxInt {
+		return nil, errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeOverflow) // on 32-bit platforms
+	}
 	_, err = data.Seek(-seekTableSize, io.SeekEnd)
 	if err != nil {
 		return nil, errors.Join(errors.New("error while seeking to seek table start"), err)
@@ -44,42 +68,115 @@ func ReadTableFromReadSeeker(data io.ReadSeeker) (*Table, error) {
 	if err != nil {
 		return nil, errors.Join(errors.New("error while reading seek table header"), err)
 	}
-	if binary.LittleEndian.Uint32(header[0:4]) != headerMagicNumber {
-		return nil, errors.Join(ErrInvalidSeekTable, ErrInvalidSeekTableHeaderMagicNumber)
-	}
-	frameSize := binary.LittleEndian.Uint32(header[4:8])
-	if frameSize != (numEntries*8)+9 {
-		return nil, errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeMismatch)
+	if err := o.checkHeader(header[:], entriesSize); err != nil {
+		return nil, err
 	}
 
 	// Read entries
-	entriesData := make([]byte, numEntries*8)
+	entriesData := make([]byte, entriesSize)
 	_, err = io.ReadFull(data, entriesData)
 	if err != nil {
 		return nil, errors.Join(errors.New("error while reading seek table entries"), err)
 	}
-	for i := uint32(0); i < numEntries; i += 4 { // Every value of the entry must be greater than zero. Empty chunks are not allowed.
-		if entriesData[0] == 0 && entriesData[1] == 0 && entriesData[2] == 0 && entriesData[3] == 0 {
-			return nil, errors.Join(ErrInvalidSeekTable, errors.New("seek table contains empty chunk entry"))
-		}
+	if err := o.checkEntries(entriesData, int(entrySize), footerOffset+9-seekTableSize); err != nil {
+		return nil, err
 	}
 
-	return &Table{entries: entriesData}, nil
+	return &Table{entries: entriesData, checksums: checksums}, nil
+}
+
+// ReadTableFromReaderAt reads the seek table located at the end of the first size bytes of r.
+func ReadTableFromReaderAt(r io.ReaderAt, size int64, opts ...ParseOption) (*Table, error) {
+	return ReadTableFromReadSeeker(io.NewSectionReader(r, 0, size), opts...)
+}
+
+// ParseTable reads the seek table located at the end of data. Data may be a serialized table alone or a whole archive.
+func ParseTable(data []byte, opts ...ParseOption) (*Table, error) {
+	return ReadTableFromReadSeeker(bytes.NewReader(data), opts...)
+}
+
+// MarshalBinary returns the table serialized as a skippable frame, as written by `WriteTableToWriter`.
+func (t *Table) MarshalBinary() ([]byte, error) {
+	buffer := bytes.NewBuffer(make([]byte, 0, t.Size()))
+	if _, err := WriteTableToWriter(t, buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the table with the table serialized in data. Unlike `ParseTable`, data must hold
+// the serialized table only.
+func (t *Table) UnmarshalBinary(data []byte) error {
+	parsed, err := ParseTable(data)
+	if err != nil {
+		return err
+	}
+	if parsed.Size() != len(data) {
+		return errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeMismatch)
+	}
+	t.replace(parsed.entries, parsed.checksums)
+	return nil
+}
+
+type jsonTable struct {
+	Checksums bool         `json:"checksums"`
+	Entries   []TableEntry `json:"entries"`
+}
+
+// MarshalJSON encodes the table as an object with the checksum flag and the list of entries.
+func (t *Table) MarshalJSON() ([]byte, error) {
+	table := jsonTable{Checksums: t.checksums, Entries: make([]TableEntry, 0, t.NumEntries())}
+	for _, entry := range t.Entries() {
+		table.Entries = append(table.Entries, entry)
+	}
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(table)
+}
+
+// UnmarshalJSON replaces the table with the table encoded by `MarshalJSON`.
+func (t *Table) UnmarshalJSON(data []byte) error {
+	var table jsonTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return err
+	}
+	parsed := NewTableFromEntries(table.Entries, table.Checksums)
+	t.replace(parsed.entries, parsed.checksums)
+	return nil
+}
+
+// replace sets new entries of the table and drops its index.
+func (t *Table) replace(entries []byte, checksums bool) {
+	t.entries = entries
+	t.checksums = checksums
+	t.lazy = nil
+	t.cached = sync.Once{}
+	t.index = nil
 }
 
 func WriteTableToWriter(t *Table, w io.Writer) (int64, error) {
+	if t.NumEntries() > MaxEntries(t.checksums) {
+		return 0, fmt.Errorf("%w: %d entries, at most %d", ErrTooManyEntries, t.NumEntries(), MaxEntries(t.checksums))
+	}
+
 	header := [8]byte{
 		0x00, 0x00, 0x00, 0x00, // magic number in little endian
 		0x00, 0x00, 0x00, 0x00, // frame size in little endian
 	}
 	binary.LittleEndian.PutUint32(header[0:4], 0x184D2A5E)
-	binary.LittleEndian.PutUint32(header[4:8], uint32(len(t.entries)+9)) // +9 for the header size
+	binary.LittleEndian.PutUint32(header[4:8], uint32(t.Size()-8)) // entries and footer
 	headerBytes, err := w.Write(header[:])
 	if err != nil {
 		return int64(headerBytes), errors.Join(errors.New("error while writing seek table header"), err)
 	}
 
-	entriesBytes, err := w.Write(t.entries)
+	var entriesBytes int
+	if t.lazy != nil {
+		entriesBytes, err = t.lazy.writeEntries(w)
+	} else {
+		entriesBytes, err = w.Write(t.entries)
+	}
 	if err != nil {
 		return int64(headerBytes + entriesBytes), errors.Join(errors.New("error while writing seek table entries"), err)
 	}
@@ -90,6 +187,9 @@ func WriteTableToWriter(t *Table, w io.Writer) (int64, error) {
 		0x00, 0x00, 0x00, 0x00, // magic number
 	}
 	binary.LittleEndian.PutUint32(footer[0:4], uint32(t.NumEntries()))
+	if t.checksums {
+		footer[4] |= checksumFlag
+	}
 	binary.LittleEndian.PutUint32(footer[5:9], footerMagicNumber)
 	footerBytes, err := w.Write(footer[:])
 	if err != nil {
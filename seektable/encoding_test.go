@@ -0,0 +1,168 @@
+package seektable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestWriteReadChecksums(t *testing.T) {
+	table := NewTable(true)
+	for i := range 10 {
+		table.AppendEntry(TableEntry{CompressedSize: uint32(i + 1), DecompressedSize: uint32(i + 100), Checksum: uint32(i) * 0x01010101})
+	}
+
+	var buf bytes.Buffer
+	n, err := WriteTableToWriter(table, &buf)
+	if err != nil {
+		t.Fatalf("WriteTableToWriter failed: %v", err)
+	}
+	if n != int64(8+10*12+9) || int(n) != table.Size() {
+		t.Fatalf("WriteTableToWriter wrote %d bytes, table size is %d", n, table.Size())
+	}
+	if descriptor := buf.Bytes()[buf.Len()-5]; descriptor != checksumFlag {
+		t.Fatalf("unexpected descriptor %08b", descriptor)
+	}
+
+	readTable, err := ReadTableFromReadSeeker(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadTableFromReadSeeker failed: %v", err)
+	}
+	if !readTable.HasChecksums() || readTable.NumEntries() != table.NumEntries() {
+		t.Fatalf("read table has checksums %v and %d entries", readTable.HasChecksums(), readTable.NumEntries())
+	}
+	for i := 0; i < table.NumEntries(); i++ {
+		if readTable.GetEntry(i) != table.GetEntry(i) {
+			t.Fatalf("entry %d mismatch: got %+v, expected %+v", i, readTable.GetEntry(i), table.GetEntry(i))
+		}
+	}
+}
+
+func TestTableSerialization(t *testing.T) {
+	for _, checksums := range []bool{false, true} {
+		entries := []TableEntry{
+			{CompressedSize: 10, DecompressedSize: 100, Checksum: 1},
+			{CompressedSize: 20, DecompressedSize: 200, Checksum: 2},
+			{CompressedSize: 30, DecompressedSize: 50, Checksum: 3},
+		}
+		table := NewTableFromEntries(entries, checksums)
+		if !checksums {
+			for i := range entries {
+				entries[i].Checksum = 0
+			}
+		}
+		checkEntries := func(t *testing.T, table *Table) {
+			t.Helper()
+			if table.HasChecksums() != checksums || table.NumEntries() != len(entries) {
+				t.Fatalf("unexpected table with checksums %v and %d entries", table.HasChecksums(), table.NumEntries())
+			}
+			for i, entry := range table.Entries() {
+				if entry != entries[i] {
+					t.Fatalf("entry %d mismatch: got %+v, expected %+v", i, entry, entries[i])
+				}
+			}
+			if to, found := table.Find(150); !found || to.EntryIndex != 1 {
+				t.Fatalf("unexpected result of Find: %+v %v", to, found)
+			}
+		}
+
+		t.Run(fmt.Sprintf("binary/checksums=%v", checksums), func(t *testing.T) {
+			data, err := table.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary failed: %v", err)
+			}
+			if len(data) != table.Size() {
+				t.Fatalf("expected %d bytes, got %d", table.Size(), len(data))
+			}
+
+			unmarshaled := NewTable(false)
+			unmarshaled.Find(0) // index of the old content must be dropped
+			if err := unmarshaled.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary failed: %v", err)
+			}
+			checkEntries(t, unmarshaled)
+			if err := unmarshaled.UnmarshalBinary(append([]byte{0}, data...)); !errors.Is(err, ErrSeekTableSizeMismatch) {
+				t.Fatalf("expected size mismatch, got %v", err)
+			}
+
+			archive := append(bytes.Repeat([]byte{7}, 60), data...)
+			parsed, err := ParseTable(archive)
+			if err != nil {
+				t.Fatalf("ParseTable failed: %v", err)
+			}
+			checkEntries(t, parsed)
+			parsed, err = ReadTableFromReaderAt(bytes.NewReader(append(archive, 1, 2, 3)), int64(len(archive)))
+			if err != nil {
+				t.Fatalf("ReadTableFromReaderAt failed: %v", err)
+			}
+			checkEntries(t, parsed)
+		})
+
+		t.Run(fmt.Sprintf("json/checksums=%v", checksums), func(t *testing.T) {
+			data, err := json.Marshal(table)
+			if err != nil {
+				t.Fatalf("json.Marshal failed: %v", err)
+			}
+			var unmarshaled Table
+			if err := json.Unmarshal(data, &unmarshaled); err != nil {
+				t.Fatalf("json.Unmarshal failed: %v", err)
+			}
+			checkEntries(t, &unmarshaled)
+		})
+	}
+
+	data, err := json.Marshal(NewTableFromEntries([]TableEntry{{CompressedSize: 1, DecompressedSize: 2}}, false))
+	if err != nil || string(data) != `{"checksums":false,"entries":[{"compressedSize":1,"decompressedSize":2}]}` {
+		t.Fatalf("unexpected JSON %s: %v", data, err)
+	}
+}
+
+// hugeArchive pretends to be an archive of the given size ending with the footer, with zeros everywhere else.
+type hugeArchive struct {
+	size   int64
+	footer [9]byte
+}
+
+func (h *hugeArchive) ReadAt(p []byte, offset int64) (int, error) {
+	clear(p)
+	for i := range p {
+		if footerIndex := offset + int64(i) - (h.size - 9); footerIndex >= 0 && footerIndex < 9 {
+			p[i] = h.footer[footerIndex]
+		}
+	}
+	return len(p), nil
+}
+
+func TestHugeSeekTable(t *testing.T) {
+	archive := &hugeArchive{size: 1 << 42}
+	binary.LittleEndian.PutUint32(archive.footer[0:4], math.MaxUint32) // number of entries
+	archive.footer[4] = checksumFlag
+	binary.LittleEndian.PutUint32(archive.footer[5:9], footerMagicNumber)
+
+	// Size of entries is computed in 64 bits and checked before anything is allocated
+	for _, mode := range []ParseMode{ParseDefault, ParseStrict, ParseLenient} {
+		if _, err := ReadTableFromReaderAt(archive, archive.size, WithParseMode(mode)); !errors.Is(err, ErrInvalidSeekTable) {
+			t.Fatalf("mode %d: expected invalid seek table, got %v", mode, err)
+		}
+	}
+	small := &hugeArchive{size: 1000, footer: archive.footer}
+	if _, err := ReadTableFromReaderAt(small, small.size); !errors.Is(err, ErrSeekTableSizeMismatch) {
+		t.Fatalf("expected size mismatch for small archive, got %v", err)
+	}
+
+	table, err := ReadTableLazily(archive, archive.size, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to read table lazily: %v", err)
+	}
+	if table.NumEntries() != math.MaxUint32 || int64(table.Size()) != math.MaxUint32*12+17 {
+		t.Fatalf("unexpected table with %d entries and size %d", table.NumEntries(), table.Size())
+	}
+	if _, err := WriteTableToWriter(table, io.Discard); !errors.Is(err, ErrTooManyEntries) {
+		t.Fatalf("expected too many entries error, got %v", err)
+	}
+}
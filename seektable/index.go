@@ -0,0 +1,203 @@
+package seektable
+
+import (
+	"sort"
+	"sync"
+)
+
+// Index finds entries of a table by decompressed or compressed offset and computes their offsets.
+type Index interface {
+	OffsetsByIndex(index int) TableOffset
+	Find(offset uint64) (TableOffset, bool)
+	FindCompressed(offset uint64) (TableOffset, bool)
+}
+
+// IndexKind selects the index of a table, trading memory for query speed.
+type IndexKind int
+
+const (
+	// FullIndex stores offsets of every entry (24 bytes per entry). Queries take O(log n).
+	FullIndex IndexKind = iota
+	// CompactIndex stores offsets of every 64th entry only (0.25 bytes per entry). Queries take O(log n) and
+	// add up to 63 entry sizes. Tables read with `ReadTableLazily` always use a compact index with one block per page.
+	CompactIndex
+)
+
+type fullIndex struct {
+	table   *Table
+	offsets []TableOffset
+}
+
+func newFullIndex(t *Table) *fullIndex {
+	offsets := make([]TableOffset, t.NumEntries())
+	var compressedOffset, decompressedOffset uint64
+	for i := 0; i < t.NumEntries(); i++ {
+		entry := t.GetEntry(i)
+		offsets[i] = TableOffset{
+			EntryIndex:                i,
+			EntryOffsetInCompressed:   compressedOffset,
+			EntryOffsetInDecompressed: decompressedOffset,
+		}
+		compressedOffset += uint64(entry.CompressedSize)
+		decompressedOffset += uint64(entry.DecompressedSize)
+	}
+	return &fullIndex{table: t, offsets: offsets}
+}
+
+func (f *fullIndex) OffsetsByIndex(index int) TableOffset {
+	return f.offsets[index]
+}
+
+func (f *fullIndex) Find(offset uint64) (TableOffset, bool) {
+	// Search linearly for small tables
+	if len(f.offsets) < 32 {
+		for _, to := range f.offsets {
+			if to.EntryOffsetInDecompressed <= offset && offset < to.EntryOffsetInDecompressed+uint64(f.table.GetEntry(to.EntryIndex).DecompressedSize) {
+				return to, true
+			}
+		}
+		return TableOffset{}, false
+	}
+
+	// Binary search for larger tables
+	low, high := 0, len(f.offsets)-1
+	for low <= high {
+		mid := (low + high) / 2
+		to := f.offsets[mid]
+		entry := f.table.GetEntry(to.EntryIndex)
+		if to.EntryOffsetInDecompressed <= offset && offset < to.EntryOffsetInDecompressed+uint64(entry.DecompressedSize) {
+			return to, true
+		} else if offset < to.EntryOffsetInDecompressed {
+			high = mid - 1
+		} else {
+			low = mid + 1
+		}
+	}
+	return TableOffset{}, false
+}
+
+func (f *fullIndex) FindCompressed(offset uint64) (TableOffset, bool) {
+	// Ends of entries never decrease, so the first entry ending after the offset contains it
+	i := sort.Search(len(f.offsets), func(i int) bool {
+		return f.offsets[i].EntryOffsetInCompressed+uint64(f.table.GetEntry(i).CompressedSize) > offset
+	})
+	if i == len(f.offsets) {
+		return TableOffset{}, false
+	}
+	return f.offsets[i], true
+}
+
+const compactIndexBlock = 64
+
+// compactIndex stores prefix sums of entry sizes at the start of every block of entries.
+// Offsets inside a block are computed from the entries of the table. Block starts are computed on demand, so
+// lookups near the start of a lazily read table do not read the whole table.
+type compactIndex struct {
+	table *Table
+	block int
+
+	mu           sync.Mutex
+	compressed   []uint64 // offset of the first entry of every block with known start
+	decompressed []uint64
+}
+
+func newCompactIndex(t *Table, block int) *compactIndex {
+	return &compactIndex{table: t, block: block, compressed: []uint64{0}, decompressed: []uint64{0}}
+}
+
+func (c *compactIndex) numBlocks() int {
+	return (c.table.NumEntries() + c.block - 1) / c.block
+}
+
+// extend computes the start of the next block. Returns false if starts of all blocks are known.
+func (c *compactIndex) extend() bool {
+	known := len(c.decompressed)
+	if known >= c.numBlocks() {
+		return false
+	}
+	compressed, decompressed := c.compressed[known-1], c.decompressed[known-1]
+	for i := (known - 1) * c.block; i < known*c.block; i++ {
+		entry := c.table.GetEntry(i)
+		compressed += uint64(entry.CompressedSize)
+		decompressed += uint64(entry.DecompressedSize)
+	}
+	c.compressed = append(c.compressed, compressed)
+	c.decompressed = append(c.decompressed, decompressed)
+	return true
+}
+
+// blockStart returns offsets of the first entry of the block.
+func (c *compactIndex) blockStart(block int) TableOffset {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.decompressed) <= block && c.extend() {
+	}
+	return TableOffset{
+		EntryIndex:                block * c.block,
+		EntryOffsetInCompressed:   c.compressed[block],
+		EntryOffsetInDecompressed: c.decompressed[block],
+	}
+}
+
+// findBlock returns the last block starting at or before the decompressed or compressed offset. Earlier blocks
+// end at or before its start.
+func (c *compactIndex) findBlock(offset uint64, compressed bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	starts := func() []uint64 {
+		if compressed {
+			return c.compressed
+		}
+		return c.decompressed
+	}
+	for starts()[len(starts())-1] <= offset && c.extend() {
+	}
+	return sort.Search(len(starts()), func(i int) bool { return starts()[i] > offset }) - 1
+}
+
+func (c *compactIndex) OffsetsByIndex(index int) TableOffset {
+	to := c.blockStart(index / c.block)
+	for i := to.EntryIndex; i < index; i++ {
+		entry := c.table.GetEntry(i)
+		to.EntryOffsetInCompressed += uint64(entry.CompressedSize)
+		to.EntryOffsetInDecompressed += uint64(entry.DecompressedSize)
+	}
+	to.EntryIndex = index
+	return to
+}
+
+func (c *compactIndex) Find(offset uint64) (TableOffset, bool) {
+	if c.table.NumEntries() == 0 {
+		return TableOffset{}, false
+	}
+
+	block := c.findBlock(offset, false)
+	to := c.blockStart(block)
+	for ; to.EntryIndex < min((block+1)*c.block, c.table.NumEntries()); to.EntryIndex++ {
+		entry := c.table.GetEntry(to.EntryIndex)
+		if offset < to.EntryOffsetInDecompressed+uint64(entry.DecompressedSize) {
+			return to, true
+		}
+		to.EntryOffsetInCompressed += uint64(entry.CompressedSize)
+		to.EntryOffsetInDecompressed += uint64(entry.DecompressedSize)
+	}
+	return TableOffset{}, false
+}
+
+func (c *compactIndex) FindCompressed(offset uint64) (TableOffset, bool) {
+	if c.table.NumEntries() == 0 {
+		return TableOffset{}, false
+	}
+
+	block := c.findBlock(offset, true)
+	to := c.blockStart(block)
+	for ; to.EntryIndex < min((block+1)*c.block, c.table.NumEntries()); to.EntryIndex++ {
+		entry := c.table.GetEntry(to.EntryIndex)
+		if offset < to.EntryOffsetInCompressed+uint64(entry.CompressedSize) {
+			return to, true
+		}
+		to.EntryOffsetInCompressed += uint64(entry.CompressedSize)
+		to.EntryOffsetInDecompressed += uint64(entry.DecompressedSize)
+	}
+	return TableOffset{}, false
+}
@@ -0,0 +1,148 @@
+package seektable
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCompactIndex(t *testing.T) {
+	for _, numEntries := range []int{0, 1, 63, 64, 65, 1000} {
+		rng := rand.New(rand.NewPCG(uint64(numEntries), 39))
+		full, compact := NewTable(false), NewTable(false)
+		compact.SetIndexKind(CompactIndex)
+		var totalSize uint64
+		for range numEntries {
+			entry := TableEntry{CompressedSize: rng.Uint32N(1000) + 1, DecompressedSize: rng.Uint32N(4000)}
+			if rng.IntN(10) == 0 {
+				entry.DecompressedSize = 0 // empty frames must never be found
+			}
+			full.AppendEntry(entry)
+			compact.AppendEntry(entry)
+			totalSize += uint64(entry.DecompressedSize)
+		}
+
+		for i := range numEntries {
+			if got, expected := compact.OffsetsByIndex(i), full.OffsetsByIndex(i); got != expected {
+				t.Fatalf("%d entries: offsets of entry %d are %+v, expected %+v", numEntries, i, got, expected)
+			}
+		}
+		var compressedSize uint64
+		for i := range numEntries {
+			compressedSize += uint64(full.GetEntry(i).CompressedSize)
+		}
+		for range 2000 {
+			offset := rng.Uint64N(compressedSize + 100)
+			var expected TableOffset
+			expectedFound := false
+			for i := range numEntries {
+				if to := full.OffsetsByIndex(i); to.EntryOffsetInCompressed <= offset && offset < to.EntryOffsetInCompressed+uint64(full.GetEntry(i).CompressedSize) {
+					expected, expectedFound = to, true
+				}
+			}
+			for _, table := range []*Table{full, compact} {
+				if got, gotFound := table.FindCompressed(offset); got != expected || gotFound != expectedFound {
+					t.Fatalf("%d entries: FindCompressed(%d) returned %+v %v, expected %+v %v", numEntries, offset, got, gotFound, expected, expectedFound)
+				}
+			}
+		}
+
+		for range 2000 {
+			offset := rng.Uint64N(totalSize + 100)
+			got, gotFound := compact.Find(offset)
+			expected, expectedFound := full.Find(offset)
+			if got != expected || gotFound != expectedFound {
+				t.Fatalf("%d entries: Find(%d) returned %+v %v, expected %+v %v", numEntries, offset, got, gotFound, expected, expectedFound)
+			}
+		}
+	}
+}
+
+func TestDecompressedIntervals(t *testing.T) {
+	table := NewTable(false)
+	for _, entry := range []TableEntry{
+		{CompressedSize: 10, DecompressedSize: 100},
+		{CompressedSize: 20, DecompressedSize: 200},
+		{CompressedSize: 5, DecompressedSize: 0},
+		{CompressedSize: 30, DecompressedSize: 300},
+	} {
+		table.AppendEntry(entry)
+	}
+
+	tests := []struct {
+		name       string
+		start, end uint64
+		expected   []DecompressedInterval
+	}{
+		{"empty", 5, 5, nil},
+		{"inside first", 2, 3, []DecompressedInterval{{0, 0, 100}}},
+		{"boundary", 9, 11, []DecompressedInterval{{0, 0, 100}, {1, 100, 300}}},
+		{"end at boundary", 10, 30, []DecompressedInterval{{1, 100, 300}}},
+		{"empty frame skipped", 31, 36, []DecompressedInterval{{3, 300, 600}}},
+		{"only empty frame", 30, 35, nil},
+		{"past the end", 50, 1000, []DecompressedInterval{{3, 300, 600}}},
+		{"seek table", 65, 80, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := table.DecompressedIntervals(test.start, test.end)
+			if len(got) != len(test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, got)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Fatalf("expected %+v, got %+v", test.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIndexFind(b *testing.B) {
+	for _, kind := range []struct {
+		name string
+		kind IndexKind
+	}{{"full", FullIndex}, {"compact", CompactIndex}} {
+		b.Run(kind.name, func(b *testing.B) {
+			table := NewTable(false)
+			table.SetIndexKind(kind.kind)
+			for range 1_000_000 {
+				table.AppendEntry(TableEntry{CompressedSize: 300, DecompressedSize: 1024})
+			}
+			table.CacheOffsets()
+			for i := 0; b.Loop(); i++ {
+				table.Find(uint64(i*7919) % (1024 * 1_000_000))
+			}
+		})
+	}
+}
+
+func TestMultiTerabyteOffsets(t *testing.T) {
+	const numEntries = 3000 // about 12 TiB of compressed and decompressed data
+	for _, kind := range []IndexKind{FullIndex, CompactIndex} {
+		table := NewTable(false)
+		table.SetIndexKind(kind)
+		for range numEntries {
+			table.AppendEntry(TableEntry{CompressedSize: math.MaxUint32, DecompressedSize: math.MaxUint32})
+		}
+
+		offset := uint64(2500)*math.MaxUint32 + 7
+		expected := TableOffset{EntryIndex: 2500, EntryOffsetInCompressed: 2500 * math.MaxUint32, EntryOffsetInDecompressed: 2500 * math.MaxUint32}
+		if to, found := table.Find(offset); !found || to != expected {
+			t.Fatalf("kind %d: Find returned %+v %v, expected %+v", kind, to, found, expected)
+		}
+		if to, found := table.FindCompressed(offset); !found || to != expected {
+			t.Fatalf("kind %d: FindCompressed returned %+v %v, expected %+v", kind, to, found, expected)
+		}
+		if to := table.OffsetsByIndex(2500); to != expected {
+			t.Fatalf("kind %d: OffsetsByIndex returned %+v, expected %+v", kind, to, expected)
+		}
+		if _, found := table.Find(numEntries * math.MaxUint32); found {
+			t.Fatalf("kind %d: found offset after the end", kind)
+		}
+		intervals := table.DecompressedIntervals(offset, offset+math.MaxUint32)
+		if len(intervals) != 2 || intervals[1].End != 2502*math.MaxUint32 {
+			t.Fatalf("kind %d: unexpected intervals %+v", kind, intervals)
+		}
+	}
+}
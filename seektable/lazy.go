@@ -0,0 +1,186 @@
+package seektable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	defaultPageEntries = 4096
+	defaultCachedPages = 16
+)
+
+// lazyEntries reads entries of a seek table on demand in pages and keeps the recently used pages.
+type lazyEntries struct {
+	r           io.ReaderAt
+	start       int64 // offset of the seek table header
+	numEntries  int
+	entrySize   int
+	pageEntries int
+	options     parseOptions
+
+	mu            sync.Mutex
+	pages         []lazyPage // at most cap(pages) pages are cached
+	clock         uint64
+	headerChecked bool
+	err           error
+}
+
+type lazyPage struct {
+	index int
+	data  []byte
+	used  uint64
+}
+
+// ReadTableLazily reads only the footer of the seek table located at the end of the first size bytes of r.
+// Entries are read on demand in pages of pageEntries entries, and at most cachedPages pages are kept in memory.
+// Zero values select 4096 entries per page and 16 pages.
+//
+// The table is read-only and safe for concurrent use. Entries of pages that cannot be read are zero, and the
+// error is reported by `Table.Err`. Finding an entry reads pages from the start of the table until the entry
+// once; after that every lookup reads at most one page. Parse options apply to the footer and the header only,
+// entries are not checked.
+func ReadTableLazily(r io.ReaderAt, size int64, pageEntries, cachedPages int, opts ...ParseOption) (*Table, error) {
+	o, err := applyParseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if pageEntries < 0 || cachedPages < 0 {
+		return nil, errors.New("page size and number of cached pages must not be negative")
+	}
+	if pageEntries == 0 {
+		pageEntries = defaultPageEntries
+	}
+	if cachedPages == 0 {
+		cachedPages = defaultCachedPages
+	}
+	if size < 17 {
+		return nil, errors.Join(ErrInvalidSeekTable, errors.New("data is too small to contain a seek table"))
+	}
+
+	var footer [9]byte
+	if _, err = r.ReadAt(footer[:], size-9); err != nil {
+		return nil, errors.Join(errors.New("error while reading seek table footer"), err)
+	}
+	numEntries, checksums, err := o.checkFooter(footer[:])
+	if err != nil {
+		return nil, err
+	}
+	entrySize := int64(8)
+	if checksums {
+		entrySize = 12
+	}
+	tableSize := 8 + numEntries*entrySize + 9
+	if tableSize > size {
+		return nil, errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeMismatch)
+	}
+	if numEntries > math.MaxInt {
+		return nil, errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeOverflow) // on 32-bit platforms
+	}
+
+	return &Table{
+		checksums: checksums,
+		lazy: &lazyEntries{
+			r:           r,
+			start:       size - tableSize,
+			numEntries:  int(numEntries),
+			entrySize:   int(entrySize),
+			pageEntries: pageEntries,
+			options:     o,
+			pages:       make([]lazyPage, 0, cachedPages),
+		},
+	}, nil
+}
+
+// entry decodes the entry with the given index.
+func (l *lazyEntries) entry(index int, checksums bool) TableEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	page := l.page(index / l.pageEntries)
+	if page == nil {
+		return TableEntry{}
+	}
+	offset := (index % l.pageEntries) * l.entrySize
+	return decodeEntry(page[offset:offset+l.entrySize], checksums)
+}
+
+// page returns entries data of the page, reading it if it is not cached. Returns nil after a read error.
+func (l *lazyEntries) page(index int) []byte {
+	if l.err != nil {
+		return nil
+	}
+	l.clock++
+	for i := range l.pages {
+		if l.pages[i].index == index {
+			l.pages[i].used = l.clock
+			return l.pages[i].data
+		}
+	}
+
+	if !l.headerChecked {
+		if l.err = l.checkHeader(); l.err != nil {
+			return nil
+		}
+		l.headerChecked = true
+	}
+
+	// Reuse the least recently used page if the cache is full
+	slot := len(l.pages)
+	if slot == cap(l.pages) {
+		slot = 0
+		for i := range l.pages {
+			if l.pages[i].used < l.pages[slot].used {
+				slot = i
+			}
+		}
+	} else {
+		l.pages = append(l.pages, lazyPage{})
+	}
+
+	entries := min(l.pageEntries, l.numEntries-index*l.pageEntries)
+	data := l.pages[slot].data
+	if cap(data) < entries*l.entrySize {
+		data = make([]byte, entries*l.entrySize)
+	}
+	data = data[:entries*l.entrySize]
+	if _, err := l.r.ReadAt(data, l.start+8+int64(index)*int64(l.pageEntries*l.entrySize)); err != nil {
+		l.err = errors.Join(fmt.Errorf("error while reading seek table entries of page %d", index), err)
+		l.pages[slot] = lazyPage{index: -1}
+		return nil
+	}
+	l.pages[slot] = lazyPage{index: index, data: data, used: l.clock}
+	return data
+}
+
+func (l *lazyEntries) checkHeader() error {
+	var header [8]byte
+	if _, err := l.r.ReadAt(header[:], l.start); err != nil {
+		return errors.Join(errors.New("error while reading seek table header"), err)
+	}
+	return l.options.checkHeader(header[:], int64(l.numEntries)*int64(l.entrySize))
+}
+
+// writeEntries writes all entries page by page, without keeping them in memory.
+func (l *lazyEntries) writeEntries(w io.Writer) (int, error) {
+	written := 0
+	for index := 0; index*l.pageEntries < l.numEntries; index++ {
+		l.mu.Lock()
+		page := l.page(index)
+		var err error
+		n := 0
+		if page == nil {
+			err = l.err
+		} else {
+			n, err = w.Write(page)
+		}
+		l.mu.Unlock()
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
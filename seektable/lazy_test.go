@@ -0,0 +1,115 @@
+package seektable
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// countingReaderAt counts reads and fails reads starting in [failFrom, failTo).
+type countingReaderAt struct {
+	r        io.ReaderAt
+	reads    int
+	failFrom int64
+	failTo   int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	c.reads++
+	if c.failFrom <= offset && offset < c.failTo {
+		return 0, errors.New("read failed")
+	}
+	return c.r.ReadAt(p, offset)
+}
+
+func TestReadTableLazily(t *testing.T) {
+	eager := NewTable(true)
+	for i := range 1000 {
+		eager.AppendEntry(TableEntry{CompressedSize: uint32(100 + i%7), DecompressedSize: uint32(1000 + i%13), Checksum: uint32(i)})
+	}
+	data := bytes.NewBuffer(bytes.Repeat([]byte{1}, 5000)) // frames before the seek table
+	if _, err := WriteTableToWriter(eager, data); err != nil {
+		t.Fatalf("failed to write table: %v", err)
+	}
+
+	source := &countingReaderAt{r: bytes.NewReader(data.Bytes())}
+	lazy, err := ReadTableLazily(source, int64(data.Len()), 100, 3)
+	if err != nil {
+		t.Fatalf("failed to read table lazily: %v", err)
+	}
+	if source.reads != 1 {
+		t.Fatalf("expected a single read when opening, got %d", source.reads)
+	}
+	if lazy.NumEntries() != 1000 || !lazy.HasChecksums() || lazy.Size() != eager.Size() {
+		t.Fatalf("unexpected lazy table: %d entries, size %d", lazy.NumEntries(), lazy.Size())
+	}
+
+	// Lookups near the start read only the first pages
+	if to, found := lazy.Find(5000); !found || to != eager.OffsetsByIndex(4) {
+		t.Fatalf("unexpected result of Find: %+v %v", to, found)
+	}
+	if source.reads > 3 { // header and one or two pages
+		t.Fatalf("expected at most 3 reads for a lookup near the start, got %d", source.reads)
+	}
+
+	for i := 0; i < 1000; i += 37 {
+		if lazy.GetEntry(i) != eager.GetEntry(i) || lazy.OffsetsByIndex(i) != eager.OffsetsByIndex(i) {
+			t.Fatalf("entry %d does not match", i)
+		}
+	}
+	for offset := uint64(0); offset < 1_100_000; offset += 9999 {
+		got, gotFound := lazy.Find(offset)
+		expected, expectedFound := eager.Find(offset)
+		if got != expected || gotFound != expectedFound {
+			t.Fatalf("Find(%d) returned %+v %v, expected %+v %v", offset, got, gotFound, expected, expectedFound)
+		}
+	}
+
+	written := bytes.NewBuffer(nil)
+	if _, err := WriteTableToWriter(lazy, written); err != nil {
+		t.Fatalf("failed to write lazy table: %v", err)
+	}
+	if !bytes.Equal(written.Bytes(), data.Bytes()[5000:]) {
+		t.Fatalf("written lazy table does not match")
+	}
+	if lazy.Err() != nil {
+		t.Fatalf("unexpected error: %v", lazy.Err())
+	}
+}
+
+func TestReadTableLazilyErrors(t *testing.T) {
+	table := NewTable(false)
+	for range 500 {
+		table.AppendEntry(TableEntry{CompressedSize: 10, DecompressedSize: 20})
+	}
+	data := bytes.NewBuffer(nil)
+	if _, err := WriteTableToWriter(table, data); err != nil {
+		t.Fatalf("failed to write table: %v", err)
+	}
+
+	// Pages after the first one cannot be read, the footer can
+	source := &countingReaderAt{r: bytes.NewReader(data.Bytes()), failFrom: 8 + 100*8, failTo: int64(data.Len()) - 9}
+	lazy, err := ReadTableLazily(source, int64(data.Len()), 100, 2)
+	if err != nil {
+		t.Fatalf("failed to read table lazily: %v", err)
+	}
+	if entry := lazy.GetEntry(10); entry.DecompressedSize != 20 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if entry := lazy.GetEntry(300); entry != (TableEntry{}) || lazy.Err() == nil {
+		t.Fatalf("expected zero entry and error, got %+v %v", entry, lazy.Err())
+	}
+
+	// Broken header is detected on the first page read
+	broken := bytes.Clone(data.Bytes())
+	broken[0] ^= 0xFF
+	lazy, err = ReadTableLazily(bytes.NewReader(broken), int64(len(broken)), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to read table lazily: %v", err)
+	}
+	lazy.GetEntry(0)
+	if !errors.Is(lazy.Err(), ErrInvalidSeekTableHeaderMagicNumber) {
+		t.Fatalf("expected header error, got %v", lazy.Err())
+	}
+}
@@ -0,0 +1,118 @@
+package seektable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ParseMode selects how strictly seek tables are checked when reading them.
+type ParseMode int
+
+const (
+	// ParseDefault checks magic numbers, the frame size in the header and that no entry has zero compressed size.
+	// Reserved bits of the descriptor are ignored.
+	ParseDefault ParseMode = iota
+	// ParseStrict enforces every rule of the seekable format: in addition to `ParseDefault`, reserved bits of the
+	// descriptor must be zero, no entry may have zero decompressed size, and total sizes must fit into int64 and into
+	// the data before the seek table. The only entry allowed without decompressed data is a head seek table: the first
+	// entry, with the compressed size of the seek table itself (see `szstd.WithHeadSeekTable`).
+	// Tables without the archive they describe, as produced by `Table.MarshalBinary`, cannot be parsed strictly.
+	ParseStrict
+	// ParseLenient accepts deviations produced by other implementations that do not prevent reading: any skippable
+	// frame magic number in the header, a wrong frame size in the header, reserved bits, and entries of empty
+	// frames with both sizes zero.
+	ParseLenient
+)
+
+var ErrSeekTableReservedBits = errors.New("reserved bits of seek table descriptor are set")
+var ErrSeekTableEmptyEntry = errors.New("seek table contains entry with zero compressed size")
+var ErrSeekTableZeroDecompressedSize = errors.New("seek table contains entry with zero decompressed size")
+var ErrSeekTableSizeOverflow = errors.New("total size of seek table entries overflows")
+
+const reservedBitsMask byte = 0b0111_1100 // bits 6-2 of the seek table descriptor
+
+// skippable frames use magic numbers 0x184D2A50 to 0x184D2A5F
+const skippableMagicMask uint32 = 0xFFFFFFF0
+
+// ParseOption is an option for reading seek tables.
+type ParseOption func(*parseOptions) error
+
+type parseOptions struct {
+	mode ParseMode
+}
+
+// WithParseMode selects how strictly the seek table is checked. Default is `ParseDefault`.
+func WithParseMode(mode ParseMode) ParseOption {
+	return func(o *parseOptions) error {
+		if mode < ParseDefault || mode > ParseLenient {
+			return fmt.Errorf("unknown parse mode %d", mode)
+		}
+		o.mode = mode
+		return nil
+	}
+}
+
+func applyParseOptions(opts []ParseOption) (parseOptions, error) {
+	var o parseOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, errors.Join(errors.New("invalid parse option"), err)
+		}
+	}
+	return o, nil
+}
+
+// checkFooter validates the footer and returns the number of entries and whether they have checksums.
+func (o parseOptions) checkFooter(footer []byte) (numEntries int64, checksums bool, err error) {
+	if binary.LittleEndian.Uint32(footer[5:9]) != footerMagicNumber {
+		return 0, false, errors.Join(ErrInvalidSeekTable, ErrInvalidSeekTableFooterMagicNumber)
+	}
+	descriptor := footer[4]
+	if o.mode == ParseStrict && descriptor&reservedBitsMask != 0 {
+		return 0, false, errors.Join(ErrInvalidSeekTable, ErrSeekTableReservedBits)
+	}
+	return int64(binary.LittleEndian.Uint32(footer[0:4])), descriptor&checksumFlag != 0, nil
+}
+
+// checkHeader validates the header of a table with entries of the given total size.
+func (o parseOptions) checkHeader(header []byte, entriesSize int64) error {
+	magic := binary.LittleEndian.Uint32(header[0:4])
+	if magic != headerMagicNumber && (o.mode != ParseLenient || magic&skippableMagicMask != headerMagicNumber&skippableMagicMask) {
+		return errors.Join(ErrInvalidSeekTable, ErrInvalidSeekTableHeaderMagicNumber)
+	}
+	if o.mode != ParseLenient && int64(binary.LittleEndian.Uint32(header[4:8])) != entriesSize+9 {
+		return errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeMismatch)
+	}
+	return nil
+}
+
+// checkEntries validates entries of a table stored after dataSize bytes of frames.
+func (o parseOptions) checkEntries(entries []byte, entrySize int, dataSize int64) error {
+	var compressed, decompressed uint64
+	for offset := 0; offset < len(entries); offset += entrySize {
+		compressedSize := binary.LittleEndian.Uint32(entries[offset : offset+4])
+		decompressedSize := binary.LittleEndian.Uint32(entries[offset+4 : offset+8])
+		if compressedSize == 0 && (o.mode != ParseLenient || decompressedSize != 0) {
+			return errors.Join(ErrInvalidSeekTable, fmt.Errorf("%w: entry %d", ErrSeekTableEmptyEntry, offset/entrySize))
+		}
+		headTable := offset == 0 && int64(compressedSize) == int64(len(entries))+17
+		if o.mode == ParseStrict && decompressedSize == 0 && !headTable {
+			return errors.Join(ErrInvalidSeekTable, fmt.Errorf("%w: entry %d", ErrSeekTableZeroDecompressedSize, offset/entrySize))
+		}
+		compressed += uint64(compressedSize)
+		decompressed += uint64(decompressedSize)
+	}
+
+	if o.mode != ParseStrict {
+		return nil
+	}
+	if compressed > math.MaxInt64 || decompressed > math.MaxInt64 {
+		return errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeOverflow)
+	}
+	if int64(compressed) > dataSize {
+		return errors.Join(ErrInvalidSeekTable, ErrSeekTableSizeMismatch, fmt.Errorf("entries describe %d bytes of frames, only %d bytes precede the seek table", compressed, dataSize))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package seektable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestParseModes(t *testing.T) {
+	// Archive with 60 bytes of frames and a seek table of 3 entries without checksums
+	archive := func(entries ...TableEntry) []byte {
+		data, err := NewTableFromEntries(entries, false).MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: %v", err)
+		}
+		return append(make([]byte, 60), data...)
+	}
+	valid := []TableEntry{{10, 100, 0}, {20, 200, 0}, {30, 300, 0}}
+	descriptor := func(archive []byte) *byte { return &archive[len(archive)-5] }
+	header := func(archive []byte) []byte { return archive[60:68] }
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected map[ParseMode]error // nil means the table is accepted
+	}{
+		{
+			name:     "valid",
+			data:     archive(valid...),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: nil, ParseLenient: nil},
+		},
+		{
+			name: "reserved bits",
+			data: func() []byte {
+				data := archive(valid...)
+				*descriptor(data) |= 0b0000_0100
+				return data
+			}(),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: ErrSeekTableReservedBits, ParseLenient: nil},
+		},
+		{
+			name: "unused bits",
+			data: func() []byte {
+				data := archive(valid...)
+				*descriptor(data) |= 0b0000_0011
+				return data
+			}(),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: nil, ParseLenient: nil},
+		},
+		{
+			name: "other skippable magic number",
+			data: func() []byte {
+				data := archive(valid...)
+				binary.LittleEndian.PutUint32(header(data)[0:4], 0x184D2A50)
+				return data
+			}(),
+			expected: map[ParseMode]error{ParseDefault: ErrInvalidSeekTableHeaderMagicNumber, ParseStrict: ErrInvalidSeekTableHeaderMagicNumber, ParseLenient: nil},
+		},
+		{
+			name: "frame size mismatch",
+			data: func() []byte {
+				data := archive(valid...)
+				binary.LittleEndian.PutUint32(header(data)[4:8], 1000)
+				return data
+			}(),
+			expected: map[ParseMode]error{ParseDefault: ErrSeekTableSizeMismatch, ParseStrict: ErrSeekTableSizeMismatch, ParseLenient: nil},
+		},
+		{
+			name:     "zero compressed size after the first entry",
+			data:     archive(TableEntry{10, 100, 0}, TableEntry{0, 5, 0}, TableEntry{30, 300, 0}),
+			expected: map[ParseMode]error{ParseDefault: ErrSeekTableEmptyEntry, ParseStrict: ErrSeekTableEmptyEntry, ParseLenient: ErrSeekTableEmptyEntry},
+		},
+		{
+			name:     "entry of empty frame with both sizes zero",
+			data:     archive(TableEntry{10, 100, 0}, TableEntry{0, 0, 0}, TableEntry{30, 300, 0}),
+			expected: map[ParseMode]error{ParseDefault: ErrSeekTableEmptyEntry, ParseStrict: ErrSeekTableEmptyEntry, ParseLenient: nil},
+		},
+		{
+			name:     "zero decompressed size",
+			data:     archive(TableEntry{10, 100, 0}, TableEntry{20, 0, 0}, TableEntry{30, 300, 0}),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: ErrSeekTableZeroDecompressedSize, ParseLenient: nil},
+		},
+		{
+			name:     "head seek table entry",
+			data:     archive(TableEntry{8 + 3*8 + 9, 0, 0}, TableEntry{10, 100, 0}, TableEntry{9, 90, 0}),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: nil, ParseLenient: nil},
+		},
+		{
+			name:     "head seek table entry after the first",
+			data:     archive(TableEntry{10, 100, 0}, TableEntry{8 + 3*8 + 9, 0, 0}, TableEntry{9, 90, 0}),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: ErrSeekTableZeroDecompressedSize, ParseLenient: nil},
+		},
+		{
+			name:     "frames larger than data",
+			data:     archive(TableEntry{10, 100, 0}, TableEntry{100, 5, 0}),
+			expected: map[ParseMode]error{ParseDefault: nil, ParseStrict: ErrSeekTableSizeMismatch, ParseLenient: nil},
+		},
+	}
+	for _, test := range tests {
+		for mode, expected := range test.expected {
+			t.Run(test.name, func(t *testing.T) {
+				table, err := ParseTable(test.data, WithParseMode(mode))
+				if expected == nil {
+					if err != nil {
+						t.Fatalf("mode %d: unexpected error: %v", mode, err)
+					}
+					if table.NumEntries() == 0 {
+						t.Fatalf("mode %d: table has no entries", mode)
+					}
+					return
+				}
+				if !errors.Is(err, expected) || !errors.Is(err, ErrInvalidSeekTable) {
+					t.Fatalf("mode %d: expected %v, got %v", mode, expected, err)
+				}
+			})
+		}
+	}
+
+	if _, err := ParseTable(archive(valid...), WithParseMode(ParseMode(10))); err == nil {
+		t.Fatalf("expected error for unknown parse mode")
+	}
+}
+
+func TestReadTableLazilyParseModes(t *testing.T) {
+	data, err := NewTableFromEntries([]TableEntry{{10, 100, 0}}, false).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	data[len(data)-5] |= 0b0100_0000
+	if _, err := ReadTableLazily(bytes.NewReader(data), int64(len(data)), 0, 0, WithParseMode(ParseStrict)); !errors.Is(err, ErrSeekTableReservedBits) {
+		t.Fatalf("expected reserved bits error, got %v", err)
+	}
+
+	binary.LittleEndian.PutUint32(data[4:8], 1000) // frame size
+	table, err := ReadTableLazily(bytes.NewReader(data), int64(len(data)), 0, 0, WithParseMode(ParseLenient))
+	if err != nil {
+		t.Fatalf("failed to read table lazily: %v", err)
+	}
+	if entry := table.GetEntry(0); entry.DecompressedSize != 100 || table.Err() != nil {
+		t.Fatalf("unexpected entry %+v: %v", entry, table.Err())
+	}
+}
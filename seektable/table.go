@@ -2,19 +2,27 @@ package seektable
 
 import (
 	"encoding/binary"
+	"iter"
+	"math"
 	"sync"
+
+	"github.com/opengs/szstd/internal/xxh64"
 )
 
 type TableEntry struct {
-	CompressedSize   uint32
-	DecompressedSize uint32
+	CompressedSize   uint32 `json:"compressedSize"`
+	DecompressedSize uint32 `json:"decompressedSize"`
+	Checksum         uint32 `json:"checksum,omitempty"` // lowest 32 bits of XXH64 of the decompressed data. Only stored if the table has checksums
 }
 
 type Table struct {
-	entries []byte
+	entries   []byte
+	checksums bool         // every entry has 4 additional bytes with checksum
+	lazy      *lazyEntries // entries are read on demand instead of stored in entries
 
-	cached        sync.Once
-	cachedOffsets []TableOffset
+	indexKind IndexKind
+	cached    sync.Once
+	index     Index
 }
 
 type TableOffset struct {
@@ -23,86 +31,195 @@ type TableOffset struct {
 	EntryOffsetInDecompressed uint64
 }
 
+// Checksum computes checksum of the decompressed frame data as stored in the seek table entries.
+func Checksum(data []byte) uint32 {
+	return uint32(xxh64.Sum64(data))
+}
+
+// NewTable creates an empty table. If checksums is set, every entry also stores the checksum of its decompressed data.
+// Zero value of the Table is an empty table without checksums.
+func NewTable(checksums bool) *Table {
+	return &Table{checksums: checksums}
+}
+
+// NewTableFromEntries creates a table with the given entries. Checksums of entries are ignored unless checksums is set.
+func NewTableFromEntries(entries []TableEntry, checksums bool) *Table {
+	t := &Table{checksums: checksums}
+	t.entries = make([]byte, 0, len(entries)*t.entrySize())
+	for _, entry := range entries {
+		t.AppendEntry(entry)
+	}
+	return t
+}
+
 func (t *Table) GetEntry(index int) TableEntry {
-	offset := index * 8
-	return TableEntry{
-		CompressedSize:   binary.LittleEndian.Uint32(t.entries[offset : offset+4]),
-		DecompressedSize: binary.LittleEndian.Uint32(t.entries[offset+4 : offset+8]),
+	if t.lazy != nil {
+		return t.lazy.entry(index, t.checksums)
+	}
+	offset := index * t.entrySize()
+	return decodeEntry(t.entries[offset:offset+t.entrySize()], t.checksums)
+}
+
+func decodeEntry(data []byte, checksums bool) TableEntry {
+	entry := TableEntry{
+		CompressedSize:   binary.LittleEndian.Uint32(data[0:4]),
+		DecompressedSize: binary.LittleEndian.Uint32(data[4:8]),
+	}
+	if checksums {
+		entry.Checksum = binary.LittleEndian.Uint32(data[8:12])
 	}
+	return entry
 }
 
+// Err returns the first error of reading entries of a lazily read table. See `ReadTableLazily`.
+func (t *Table) Err() error {
+	if t.lazy == nil {
+		return nil
+	}
+	t.lazy.mu.Lock()
+	defer t.lazy.mu.Unlock()
+	return t.lazy.err
+}
+
+// AppendEntry adds the entry at the end of the table. Panics if the table is read lazily.
 func (t *Table) AppendEntry(entry TableEntry) {
-	t.entries = append(t.entries, 0, 0, 0, 0, 0, 0, 0, 0)
+	if t.lazy != nil {
+		panic("seektable: lazily read table is read-only")
+	}
+	t.entries = append(t.entries, make([]byte, t.entrySize())...)
 	t.SetEntry(t.NumEntries()-1, entry)
 }
 
+// SetEntry overwrites the entry. Checksum is ignored if the table has no checksums. Panics if the table is read lazily.
 func (t *Table) SetEntry(index int, entry TableEntry) {
-	offset := index * 8
+	if t.lazy != nil {
+		panic("seektable: lazily read table is read-only")
+	}
+	offset := index * t.entrySize()
 	binary.LittleEndian.PutUint32(t.entries[offset:offset+4], entry.CompressedSize)
 	binary.LittleEndian.PutUint32(t.entries[offset+4:offset+8], entry.DecompressedSize)
+	if t.checksums {
+		binary.LittleEndian.PutUint32(t.entries[offset+8:offset+12], entry.Checksum)
+	}
+}
+
+// Entries iterates over indexes and entries of the table.
+func (t *Table) Entries() iter.Seq2[int, TableEntry] {
+	return func(yield func(int, TableEntry) bool) {
+		for i := 0; i < t.NumEntries(); i++ {
+			if !yield(i, t.GetEntry(i)) {
+				return
+			}
+		}
+	}
+}
+
+// MaxEntries returns the largest number of entries of a table. It is limited by the 32-bit frame size in the
+// header of the seek table rather than by the 32-bit number of entries in the footer.
+func MaxEntries(checksums bool) int {
+	entrySize := 8
+	if checksums {
+		entrySize = 12
+	}
+	return (math.MaxUint32 - 9) / entrySize
 }
 
 func (t *Table) NumEntries() int {
-	return len(t.entries) / 8
+	if t.lazy != nil {
+		return t.lazy.numEntries
+	}
+	return len(t.entries) / t.entrySize()
+}
+
+// HasChecksums reports whether entries store checksums of the decompressed data.
+func (t *Table) HasChecksums() bool {
+	return t.checksums
+}
+
+func (t *Table) entrySize() int {
+	if t.checksums {
+		return 12
+	}
+	return 8
 }
 
 func (t *Table) OffsetsByIndex(index int) TableOffset {
 	t.CacheOffsets()
 
-	return t.cachedOffsets[index]
+	return t.index.OffsetsByIndex(index)
 }
 
 func (t *Table) Size() int {
-	return len(t.entries) + 8 + 9 // entries + header + footer
+	return t.NumEntries()*t.entrySize() + 8 + 9 // entries + header + footer
 }
 
 // Get the TableOffset for a given decompressed offset
 func (t *Table) Find(offset uint64) (TableOffset, bool) {
 	t.CacheOffsets()
 
-	// Search linearly for small tables
-	if len(t.cachedOffsets) < 32 {
-		for _, to := range t.cachedOffsets {
-			if to.EntryOffsetInDecompressed <= offset && offset < to.EntryOffsetInDecompressed+uint64(t.GetEntry(to.EntryIndex).DecompressedSize) {
-				return to, true
-			}
-		}
-		return TableOffset{}, false
+	return t.index.Find(offset)
+}
+
+// FindCompressed returns the entry containing the compressed offset, relative to the start of the first frame.
+// Offsets after the last entry, such as offsets of the seek table itself, are not found.
+func (t *Table) FindCompressed(offset uint64) (TableOffset, bool) {
+	t.CacheOffsets()
+
+	return t.index.FindCompressed(offset)
+}
+
+// DecompressedInterval is the half-open range [Start, End) of decompressed data stored in the entry EntryIndex.
+type DecompressedInterval struct {
+	EntryIndex int
+	Start      uint64
+	End        uint64
+}
+
+// DecompressedIntervals returns decompressed ranges of all entries overlapping the compressed range [start, end),
+// in order. A damaged byte makes the whole frame unreadable, so every overlapping entry is affected in full.
+// Entries without decompressed data are skipped, and the part of the range after the last entry is ignored.
+func (t *Table) DecompressedIntervals(start, end uint64) []DecompressedInterval {
+	if start >= end {
+		return nil
+	}
+	to, found := t.FindCompressed(start)
+	if !found {
+		return nil
 	}
 
-	// Binary search for larger tables
-	low, high := 0, len(t.cachedOffsets)-1
-	for low <= high {
-		mid := (low + high) / 2
-		to := t.cachedOffsets[mid]
-		entry := t.GetEntry(to.EntryIndex)
-		if to.EntryOffsetInDecompressed <= offset && offset < to.EntryOffsetInDecompressed+uint64(entry.DecompressedSize) {
-			return to, true
-		} else if offset < to.EntryOffsetInDecompressed {
-			high = mid - 1
-		} else {
-			low = mid + 1
+	var intervals []DecompressedInterval
+	for index := to.EntryIndex; index < t.NumEntries() && to.EntryOffsetInCompressed < end; index++ {
+		entry := t.GetEntry(index)
+		if entry.DecompressedSize > 0 {
+			intervals = append(intervals, DecompressedInterval{
+				EntryIndex: index,
+				Start:      to.EntryOffsetInDecompressed,
+				End:        to.EntryOffsetInDecompressed + uint64(entry.DecompressedSize),
+			})
 		}
+		to.EntryOffsetInCompressed += uint64(entry.CompressedSize)
+		to.EntryOffsetInDecompressed += uint64(entry.DecompressedSize)
 	}
-	return TableOffset{}, false
+	return intervals
+}
+
+// SetIndexKind selects the index built by `CacheOffsets`. Must be called before the first query of offsets,
+// later calls have no effect. Default is `FullIndex`. Ignored by lazily read tables.
+func (t *Table) SetIndexKind(kind IndexKind) {
+	t.indexKind = kind
 }
 
-// CacheOffsets precomputes the decompressed and compressed offsets for each entry.
+// CacheOffsets builds the index used to find entries and their offsets, as selected by `SetIndexKind`.
 // Can be run multiple times safely. Will be run automatically on first Find call if not run before.
 func (t *Table) CacheOffsets() {
 	t.cached.Do(func() {
-		offsets := make([]TableOffset, t.NumEntries())
-		var compressedOffset, decompressedOffset uint64
-		for i := 0; i < t.NumEntries(); i++ {
-			entry := t.GetEntry(i)
-			offsets[i] = TableOffset{
-				EntryIndex:                i,
-				EntryOffsetInCompressed:   compressedOffset,
-				EntryOffsetInDecompressed: decompressedOffset,
-			}
-			compressedOffset += uint64(entry.CompressedSize)
-			decompressedOffset += uint64(entry.DecompressedSize)
+		switch {
+		case t.lazy != nil:
+			t.index = newCompactIndex(t, t.lazy.pageEntries)
+		case t.indexKind == CompactIndex:
+			t.index = newCompactIndex(t, compactIndexBlock)
+		default:
+			t.index = newFullIndex(t)
 		}
-		t.cachedOffsets = offsets
 	})
 }
@@ -0,0 +1,144 @@
+package szstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+// Skippable frames written by this package between the last data frame and the seek table.
+// Zstd decoders ignore them, and the seek table does not describe them, so they are invisible to
+// regular readers. The payload starts with 4 bytes identifying its kind.
+const trailerMagicNumber uint32 = 0x184D2A5D
+
+const (
+	skippableMagicMask uint32 = 0xFFFFFFF0
+	skippableMagicBase uint32 = 0x184D2A50
+)
+
+var ErrTrailerNotFound = errors.New("trailing skippable frame not found")
+
+type trailer struct {
+	kind    [4]byte
+	payload []byte
+}
+
+// appendTrailerFrame appends a complete skippable frame holding the payload of the given kind.
+func appendTrailerFrame(dst []byte, kind [4]byte, payload []byte) []byte {
+	var header [12]byte
+	binary.LittleEndian.PutUint32(header[0:4], trailerMagicNumber)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+len(payload)))
+	copy(header[8:12], kind[:])
+	dst = append(dst, header[:]...)
+	return append(dst, payload...)
+}
+
+// readTrailers reads all skippable frames located between the last data frame and the seek table.
+// Only frames written with trailerMagicNumber are returned; other skippable frames are skipped.
+func readTrailers(r io.ReadSeeker, table *seektable.Table) ([]trailer, error) {
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to seek to end of data"), err)
+	}
+	start := int64(framesEnd(table))
+	end := fileSize - int64(table.Size())
+	if start > end {
+		return nil, fmt.Errorf("seek table describes %d bytes of frames, but only %d bytes available", start, end)
+	}
+
+	frames, stop, err := scanSkippableFrames(r, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if stop != end {
+		return nil, fmt.Errorf("unexpected data at offset %d between frames and seek table", stop)
+	}
+
+	var trailers []trailer
+	for _, frame := range frames {
+		if frame.magic != trailerMagicNumber || frame.size < 4 {
+			continue
+		}
+		if _, err := r.Seek(frame.offset+8, io.SeekStart); err != nil {
+			return nil, errors.Join(errors.New("failed to seek to skippable frame payload"), err)
+		}
+		data := make([]byte, frame.size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, errors.Join(errors.New("failed to read skippable frame payload"), err)
+		}
+		trailers = append(trailers, trailer{kind: [4]byte(data[0:4]), payload: data[4:]})
+	}
+
+	return trailers, nil
+}
+
+type skippableFrame struct {
+	offset int64 // offset of the frame header
+	magic  uint32
+	size   int64 // payload size
+}
+
+// scanSkippableFrames lists consecutive skippable frames in [start, end) without reading their payloads.
+// Returns the frames and the offset of the first byte which is not part of a complete skippable frame.
+func scanSkippableFrames(r io.ReadSeeker, start, end int64) ([]skippableFrame, int64, error) {
+	var frames []skippableFrame
+	position := start
+	for end-position >= 8 {
+		if _, err := r.Seek(position, io.SeekStart); err != nil {
+			return nil, 0, errors.Join(errors.New("failed to seek to skippable frame"), err)
+		}
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, 0, errors.Join(errors.New("failed to read skippable frame header"), err)
+		}
+		magic := binary.LittleEndian.Uint32(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if magic&skippableMagicMask != skippableMagicBase || position+8+size > end {
+			break
+		}
+		frames = append(frames, skippableFrame{offset: position, magic: magic, size: size})
+		position += 8 + size
+	}
+	return frames, position, nil
+}
+
+// readTrailer returns the payload of the first trailing skippable frame of the given kind.
+func readTrailer(r io.ReadSeeker, kind [4]byte) ([]byte, error) {
+	table, err := seektable.ReadTableFromReadSeeker(r)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read seek table"), err)
+	}
+	trailers, err := readTrailers(r, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trailers {
+		if t.kind == kind {
+			return t.payload, nil
+		}
+	}
+	return nil, ErrTrailerNotFound
+}
+
+// framesEnd returns the compressed offset right after the last frame described by the table.
+func framesEnd(table *seektable.Table) uint64 {
+	if table.NumEntries() == 0 {
+		return 0
+	}
+	lastOffsets := table.OffsetsByIndex(table.NumEntries() - 1)
+	lastEntry := table.GetEntry(table.NumEntries() - 1)
+	return lastOffsets.EntryOffsetInCompressed + uint64(lastEntry.CompressedSize)
+}
+
+// decompressedSize returns the total decompressed size of frames described by the table.
+func decompressedSize(table *seektable.Table) uint64 {
+	if table.NumEntries() == 0 {
+		return 0
+	}
+	lastOffsets := table.OffsetsByIndex(table.NumEntries() - 1)
+	lastEntry := table.GetEntry(table.NumEntries() - 1)
+	return lastOffsets.EntryOffsetInDecompressed + uint64(lastEntry.DecompressedSize)
+}
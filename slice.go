@@ -0,0 +1,180 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// Slice writes the decompressed range [start, end) of the src archive into dst as a new seekable archive.
+// Frames fully inside the range are copied verbatim. Only the covered parts of the frames at both edges of the
+// range are decoded and compressed again with the target options, each into its own frame. The source options apply
+// to src as for `NewReadSeekerWithOptions`.
+// Seek table entries of copied frames keep their checksums; if the writer stores checksums and the source does
+// not, copied frames are decoded to calculate them.
+func Slice(dst io.Writer, src io.ReadSeeker, start, end int64, opts ...TranscodeOption) error {
+	to, err := applyTranscodeOptions(opts)
+	if err != nil {
+		return err
+	}
+	reader, err := NewReadSeekerWithOptions(src, to.readerOptions...)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := reader.checkRange(start, end); err != nil {
+		return err
+	}
+
+	// Frames of the writer only hold the edges, copied frames do not pass its buffers
+	writer, err := NewWriterWithOptions(dst, reader.edgeSize(start, end), to.writerOptions...)
+	if err != nil {
+		return err
+	}
+
+	var decoded []byte
+	err = reader.copyRange(start, end, func(frame Frame, raw []byte) error {
+		entry := frame.TableEntry
+		if writer.seekTable.HasChecksums() && !reader.seekTable.HasChecksums() {
+			var err error
+			if decoded, err = reader.decodeEntry(raw, frame.TableEntry, decoded[:0]); err != nil {
+				return &FrameError{Frame: frame, Err: err}
+			}
+			entry.Checksum = seektable.Checksum(decoded)
+		}
+		return writer.WriteRawFrame(raw, entry)
+	}, func(data []byte) error {
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+		return writer.Flush()
+	})
+	if err != nil {
+		return errors.Join(err, writer.Close())
+	}
+	return writer.Close()
+}
+
+// WriteZstdRange writes the decompressed range [start, end) into dst as a plain zstd stream without a seek table,
+// for example to serve it with `Content-Encoding: zstd`. Frames fully inside the range are written as is, so
+// nothing is decompressed on the server except the frames at both edges, whose covered parts are compressed again
+// with the encoder options of the writer options. Returns the decompressed length of the written stream. An empty range writes
+// nothing.
+func (r *ReadSeeker) WriteZstdRange(dst io.Writer, start, end int64, opts ...WriterOption) (int64, error) {
+	o, err := applyWriterOptions(opts)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.checkRange(start, end); err != nil {
+		return 0, err
+	}
+
+	var encoder *zstd.Encoder // only needed for edge frames
+	defer func() {
+		if encoder != nil {
+			encoder.Close()
+		}
+	}()
+	var compressed []byte
+	err = r.copyRange(start, end, func(frame Frame, raw []byte) error {
+		_, err := dst.Write(raw)
+		return err
+	}, func(data []byte) error {
+		if encoder == nil {
+			var err error
+			encoder, err = zstd.NewWriter(nil, append([]zstd.EOption{zstd.WithEncoderConcurrency(1)}, o.encoderOptions...)...)
+			if err != nil {
+				return errors.Join(errors.New("failed to create zstd encoder"), err)
+			}
+		}
+		compressed = encoder.EncodeAll(data, compressed[:0])
+		_, err := dst.Write(compressed)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return end - start, nil
+}
+
+// edgeSize returns the size of the larger covered part of the frames at both edges of the range [start, end) that
+// are not fully inside it, at least 1. The range must be valid.
+func (r *ReadSeeker) edgeSize(start, end int64) int {
+	size := int64(1)
+	if start == end {
+		return int(size)
+	}
+	for _, offset := range []int64{start, end - 1} {
+		tableOffset, found := r.seekTable.Find(uint64(offset))
+		if !found {
+			continue
+		}
+		frameRange := r.Frame(tableOffset.EntryIndex).DecompressedRange()
+		if covered := min(end, int64(frameRange.End)) - max(start, int64(frameRange.Start)); covered < int64(frameRange.Len()) {
+			size = max(size, covered)
+		}
+	}
+	return int(min(size, MaxFrameSize))
+}
+
+// checkRange checks that [start, end) is a valid decompressed range of the archive.
+func (r *ReadSeeker) checkRange(start, end int64) error {
+	if r.Size() < 0 {
+		return r.tableError()
+	}
+	if start < 0 || start > end || end > r.Size() {
+		return fmt.Errorf("range [%d, %d) is outside of decompressed data [0, %d)", start, end, r.Size())
+	}
+	return nil
+}
+
+// copyRange passes data of the decompressed range [start, end) in order: compressed bytes of frames fully inside
+// the range to whole, and decompressed covered parts of the edge frames to partial. Slices are reused between calls.
+func (r *ReadSeeker) copyRange(start, end int64, whole func(frame Frame, raw []byte) error, partial func(data []byte) error) error {
+	if err := r.checkRange(start, end); err != nil {
+		return err
+	}
+	if start == end {
+		return nil
+	}
+
+	first, _ := r.seekTable.Find(uint64(start))
+	var raw, decoded []byte
+	for i := first.EntryIndex; i < r.NumFrames(); i++ {
+		frame := r.Frame(i)
+		frameRange := frame.DecompressedRange()
+		if frameRange.Start >= uint64(end) {
+			break
+		}
+		if frameRange.Len() == 0 {
+			continue
+		}
+
+		var err error
+		if frameRange.Start >= uint64(start) && frameRange.End <= uint64(end) {
+			if raw, err = r.readRawFrame(i, raw[:0]); err != nil {
+				return r.frameError(i, err)
+			}
+			if err := whole(frame, raw); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if decoded, err = r.DecodeFrame(i, decoded[:0]); err != nil {
+			return err
+		}
+		if uint64(len(decoded)) != frameRange.Len() {
+			return r.frameError(i, errors.Join(ErrFrameSizeMismatch, fmt.Errorf("decompressed %d bytes, expected %d", len(decoded), frameRange.Len())))
+		}
+		from := uint64(max(start, int64(frameRange.Start))) - frameRange.Start
+		to := uint64(min(end, int64(frameRange.End))) - frameRange.Start
+		if err := partial(decoded[from:to]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package szstd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestSlice(t *testing.T) {
+	const frameSize = 4 * 1024
+	data := generateTestData(10*frameSize+700, 36)
+	archive := compressTestData(t, data, frameSize)
+	source, err := NewReadSeeker(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("failed to open source archive: %v", err)
+	}
+	defer source.Close()
+
+	tests := []struct {
+		name         string
+		start, end   int64
+		numFrames    int
+		copiedFrames []int // source frames expected to be copied verbatim
+	}{
+		{"whole archive", 0, int64(len(data)), 11, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"unaligned", 1000, 7*frameSize + 5, 8, []int{1, 2, 3, 4, 5, 6}},
+		{"aligned", frameSize, 3 * frameSize, 2, []int{1, 2}},
+		{"inside one frame", 5000, 6000, 1, nil},
+		{"adjacent edges", frameSize - 10, frameSize + 10, 2, nil},
+		{"empty", 100, 100, 0, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sliced := bytes.NewBuffer(nil)
+			if err := Slice(sliced, bytes.NewReader(archive), test.start, test.end, WithTargetOptions(WithChecksums(true))); err != nil {
+				t.Fatalf("failed to slice: %v", err)
+			}
+			report, err := Verify(bytes.NewReader(sliced.Bytes()), 1)
+			if err != nil || !report.OK() || !report.ChecksumsChecked {
+				t.Fatalf("sliced archive failed verification: %v %+v", err, report)
+			}
+
+			reader, err := NewReadSeeker(bytes.NewReader(sliced.Bytes()))
+			if err != nil {
+				t.Fatalf("failed to open sliced archive: %v", err)
+			}
+			defer reader.Close()
+			decompressed, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read sliced archive: %v", err)
+			}
+			if !bytes.Equal(decompressed, data[test.start:test.end]) {
+				t.Fatalf("sliced data does not match")
+			}
+			if reader.NumFrames() != test.numFrames {
+				t.Fatalf("expected %d frames, got %d", test.numFrames, reader.NumFrames())
+			}
+
+			// Copied frames follow the edge frame, if the range does not start at a frame boundary
+			offset := 0
+			if test.start%frameSize != 0 {
+				offset = 1
+			}
+			for i, index := range test.copiedFrames {
+				expected, err := source.RawFrame(index)
+				if err != nil {
+					t.Fatalf("failed to read source frame %d: %v", index, err)
+				}
+				got, err := reader.RawFrame(offset + i)
+				if err != nil {
+					t.Fatalf("failed to read sliced frame %d: %v", offset+i, err)
+				}
+				if !bytes.Equal(got, expected) {
+					t.Fatalf("frame %d was not copied verbatim", index)
+				}
+			}
+		})
+	}
+
+	for _, r := range [][2]int64{{-1, 10}, {10, 5}, {0, int64(len(data)) + 1}} {
+		if err := Slice(io.Discard, bytes.NewReader(archive), r[0], r[1]); err == nil {
+			t.Fatalf("expected error for range %v", r)
+		}
+	}
+
+	t.Run("source options", func(t *testing.T) {
+		err := Slice(io.Discard, bytes.NewReader(archive), 0, 10, WithSourceOptions(WithMaxDecompressedSize(1000)))
+		if !errors.Is(err, ErrDecompressedSizeLimit) {
+			t.Fatalf("expected decompressed size limit error, got %v", err)
+		}
+	})
+
+	t.Run("large frames", func(t *testing.T) {
+		// Only edges are buffered by the writer, copied frames are not
+		const largeFrameSize = 16 * 1024 * 1024
+		large := compressTestData(t, make([]byte, 4*largeFrameSize), largeFrameSize)
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		sliced := bytes.NewBuffer(make([]byte, 0, len(large)))
+		if err := Slice(sliced, bytes.NewReader(large), largeFrameSize, 3*largeFrameSize); err != nil {
+			t.Fatalf("failed to slice: %v", err)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > largeFrameSize/4 {
+			t.Fatalf("slice allocated %d bytes to copy frames of %d bytes", allocated, largeFrameSize)
+		}
+		if info, err := Inspect(bytes.NewReader(sliced.Bytes())); err != nil || info.NumFrames != 2 {
+			t.Fatalf("unexpected sliced archive %+v: %v", info, err)
+		}
+	})
+}
+
+func TestWriteZstdRange(t *testing.T) {
+	const frameSize = 4 * 1024
+	data := generateTestData(10*frameSize+700, 37)
+	reader, err := NewReadSeeker(bytes.NewReader(compressTestData(t, data, frameSize)))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer reader.Close()
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("failed to create zstd decoder: %v", err)
+	}
+	defer decoder.Close()
+
+	for _, r := range [][2]int64{{0, int64(len(data))}, {1000, 7*frameSize + 5}, {5000, 6000}, {3 * frameSize, 5 * frameSize}, {10, 10}} {
+		stream := bytes.NewBuffer(nil)
+		length, err := reader.WriteZstdRange(stream, r[0], r[1])
+		if err != nil {
+			t.Fatalf("failed to write range %v: %v", r, err)
+		}
+		if length != r[1]-r[0] {
+			t.Fatalf("expected length %d for range %v, got %d", r[1]-r[0], r, length)
+		}
+		decompressed, err := decoder.DecodeAll(stream.Bytes(), nil)
+		if err != nil {
+			t.Fatalf("failed to decode stream of range %v: %v", r, err)
+		}
+		if !bytes.Equal(decompressed, data[r[0]:r[1]]) {
+			t.Fatalf("decoded stream of range %v does not match", r)
+		}
+	}
+
+	// Interior frames are written verbatim
+	stream := bytes.NewBuffer(nil)
+	if _, err := reader.WriteZstdRange(stream, 1000, 7*frameSize+5); err != nil {
+		t.Fatalf("failed to write range: %v", err)
+	}
+	for i := 1; i <= 6; i++ {
+		raw, err := reader.RawFrame(i)
+		if err != nil {
+			t.Fatalf("failed to read frame %d: %v", i, err)
+		}
+		if !bytes.Contains(stream.Bytes(), raw) {
+			t.Fatalf("frame %d was not copied verbatim", i)
+		}
+	}
+
+	if _, err := reader.WriteZstdRange(io.Discard, 0, int64(len(data))+1); err == nil {
+		t.Fatalf("expected error for range outside of data")
+	}
+}
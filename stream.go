@@ -0,0 +1,341 @@
+package szstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// magic number of the skippable frame holding a seek table
+const seekTableMagicNumber uint32 = 0x184D2A5E
+
+// Reader decompresses an archive sequentially from a stream that cannot seek, such as a pipe or a socket.
+//
+// If the archive starts with a copy of the seek table (see `WithHeadSeekTable`), the table gives the total size for
+// progress reporting, every frame is checked against its entry, and `Skip` discards whole frames without decoding
+// them. Otherwise frames are located by parsing their headers, and skippable frames are ignored, including the seek
+// table at the end. `WithSeekTableValidation` checks the frames against that table once it arrives. `Skip` discards
+// such frames without decoding them if their headers declare the decompressed size, as frames of this package do.
+//
+// Archives following each other in the stream are read one after another. Reader options apply as for
+// `NewReadSeekerWithOptions`, except those selecting how the seek table is kept in memory. It is not safe for concurrent use.
+type Reader struct {
+	r       *bufio.Reader
+	table   *seektable.Table // head seek table, nil if the archive has none
+	decoder *zstd.Decoder    // decoder of frames of known size, created by `newTableDecoder`
+
+	unsizedDecoder *zstd.Decoder // decoder of frames without declared size, created when first needed
+
+	next   seektable.TableOffset // position of the next frame to read from r
+	offset uint64                // decompressed offset of the next byte returned by Read
+
+	frame      []byte // not yet returned data of the current frame
+	compressed []byte
+	decoded    []byte
+
+	validate      bool
+	frames        []streamFrame // frames read since the last seek table, kept only for validation
+	archiveFrames int           // number of frames read since the last seek table
+
+	options        readerOptions // limits of frame sizes
+	onCorruptFrame func(*FrameError) CorruptFrameAction
+}
+
+// streamFrame is a frame read by `Reader`, kept to check it against the seek table at the end of the archive.
+type streamFrame struct {
+	seektable.TableEntry
+	sizeKnown     bool // decompressed size is known
+	checksumKnown bool // checksum was calculated from decoded data
+}
+
+// NewReader returns a reader of the archive in r. If the archive starts with a seek table, it is read before
+// returning.
+func NewReader(r io.Reader, opts ...ReaderOption) (*Reader, error) {
+	o, err := applyReaderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := newTableDecoder(o)
+	if err != nil {
+		return nil, err
+	}
+	reader := &Reader{
+		r:              bufio.NewReaderSize(r, 64*1024),
+		decoder:        decoder,
+		validate:       o.validateSeekTable,
+		options:        o,
+		onCorruptFrame: o.onCorruptFrame,
+	}
+
+	magic, err := reader.r.Peek(4)
+	if err != nil {
+		decoder.Close()
+		return nil, errors.Join(errors.New("failed to read first frame header"), err)
+	}
+	if binary.LittleEndian.Uint32(magic) == seekTableMagicNumber {
+		if _, err := reader.nextFrame(0); err != nil {
+			decoder.Close()
+			return nil, err
+		}
+		if reader.table != nil {
+			if err := o.checkTable(reader.table); err != nil {
+				decoder.Close()
+				return nil, err
+			}
+		}
+	}
+	return reader, nil
+}
+
+// Table returns the seek table read from the head of the archive, or nil if the archive does not start with one.
+// Its first entry is the head seek table itself.
+func (r *Reader) Table() *seektable.Table {
+	return r.table
+}
+
+// Size returns the total decompressed size of the archive from the head seek table, or -1 if it is unknown.
+func (r *Reader) Size() int64 {
+	if r.table == nil {
+		return -1
+	}
+	return int64(decompressedSize(r.table))
+}
+
+// Offset returns the decompressed offset of the next byte returned by `Read`.
+func (r *Reader) Offset() int64 {
+	return int64(r.offset)
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.frame) == 0 {
+		if _, err := r.nextFrame(0); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.frame)
+	r.frame = r.frame[n:]
+	r.offset += uint64(n)
+	return n, nil
+}
+
+// Skip discards the next n bytes of decompressed data and returns the number of bytes discarded, which is less
+// than n only at the end of the stream (with `io.EOF`) or on error. Frames of known size that are skipped entirely
+// are not decoded.
+func (r *Reader) Skip(n int64) (int64, error) {
+	var skipped int64
+	for skipped < n {
+		if len(r.frame) > 0 {
+			discard := int(min(int64(len(r.frame)), n-skipped))
+			r.frame = r.frame[discard:]
+			r.offset += uint64(discard)
+			skipped += int64(discard)
+			continue
+		}
+		discarded, err := r.nextFrame(n - skipped)
+		r.offset += uint64(discarded)
+		skipped += discarded
+		if err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// nextFrame reads the next frame from the stream. If the decompressed size of the frame is known and at most
+// discard, the frame is not decoded and its size is returned. Otherwise its data is made available in r.frame.
+// Returns io.EOF at the end of the stream.
+//
+// A frame that cannot be decoded is passed to the corrupt frame handler. Read errors of the stream are always
+// returned, since the position of the next frame is lost.
+func (r *Reader) nextFrame(discard int64) (int64, error) {
+	frame := Frame{TableOffset: r.next}
+	fromHead := r.table != nil && r.next.EntryIndex < r.table.NumEntries()
+	sizeKnown := false
+	var err error
+	if fromHead {
+		// Frame described by the head seek table
+		frame.TableEntry = r.table.GetEntry(r.next.EntryIndex)
+		sizeKnown = true
+		if int64(frame.DecompressedSize) <= discard {
+			if _, err := io.CopyN(io.Discard, r.r, int64(frame.CompressedSize)); err != nil {
+				return 0, errors.Join(fmt.Errorf("failed to read frame %d", frame.EntryIndex), noEOF(err))
+			}
+			r.keep(frame, true, false)
+			return int64(frame.DecompressedSize), nil
+		}
+		if err := r.options.checkFrame(frame); err != nil {
+			// Not passed to the corrupt frame handler, since replacing the frame with zeros needs as much memory
+			return 0, &FrameError{Frame: frame, Err: err}
+		}
+		r.compressed = slices.Grow(r.compressed[:0], int(frame.CompressedSize))[:frame.CompressedSize]
+		if _, err := io.ReadFull(r.r, r.compressed); err != nil {
+			return 0, errors.Join(fmt.Errorf("failed to read frame %d", frame.EntryIndex), noEOF(err))
+		}
+	} else {
+		// Frame located by its headers
+		var scanned scannedFrame
+		r.compressed, scanned, err = readFrame(r.r, r.compressed[:0], r.options.maxFrameSize)
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, errors.Join(fmt.Errorf("failed to read frame at offset %d", frame.EntryOffsetInCompressed), err)
+		}
+		if scanned.size > math.MaxUint32 {
+			return 0, fmt.Errorf("%w: frame at offset %d has %d bytes", ErrFrameTooLarge, frame.EntryOffsetInCompressed, scanned.size)
+		}
+		if scanned.skippable {
+			return 0, r.skippableFrame(frame)
+		}
+		frame.CompressedSize = uint32(scanned.size)
+		if scanned.hasContentSize && scanned.contentSize <= math.MaxUint32 {
+			frame.DecompressedSize = uint32(scanned.contentSize)
+			sizeKnown = true
+			if int64(frame.DecompressedSize) <= discard {
+				r.keep(frame, true, false)
+				return int64(frame.DecompressedSize), nil
+			}
+		}
+		if err := r.options.checkFrame(frame); err != nil {
+			return 0, &FrameError{Frame: frame, Err: err}
+		}
+	}
+
+	if sizeKnown {
+		// Decoding stops as soon as the frame exceeds its declared size
+		r.decoded, err = decodeEntry(r.decoder, r.compressed, frame.TableEntry, r.decoded[:0])
+	} else {
+		if r.unsizedDecoder == nil {
+			if r.unsizedDecoder, err = newDecoder(r.options); err != nil {
+				return 0, err
+			}
+		}
+		r.decoded, err = r.unsizedDecoder.DecodeAll(r.compressed, r.decoded[:0])
+		if err != nil {
+			err = errors.Join(errors.New("failed to decode frame"), err)
+		}
+	}
+	switch {
+	case err != nil:
+	case sizeKnown:
+		err = checkDecodedFrame(frame, r.decoded, fromHead && r.table.HasChecksums())
+	case int64(len(r.decoded)) > math.MaxUint32:
+		err = fmt.Errorf("%w: frame decompressed to %d bytes", ErrFrameTooLarge, len(r.decoded))
+	default:
+		frame.DecompressedSize = uint32(len(r.decoded))
+		sizeKnown = true
+		err = r.options.checkFrame(frame)
+	}
+	if err == nil {
+		if r.validate {
+			frame.Checksum = seektable.Checksum(r.decoded)
+		}
+		r.keep(frame, true, r.validate)
+		r.frame = r.decoded
+		return 0, nil
+	}
+
+	frameErr := &FrameError{Frame: frame, Err: err}
+	action := CorruptFrameFail
+	if r.onCorruptFrame != nil {
+		action = r.onCorruptFrame(frameErr)
+	}
+	switch {
+	case action == CorruptFrameZeroFill && sizeKnown:
+		size := int(frame.DecompressedSize)
+		r.decoded = slices.Grow(r.decoded[:0], size)[:size]
+		clear(r.decoded)
+		r.frame = r.decoded
+	case action == CorruptFrameSkip || action == CorruptFrameZeroFill:
+		// Frames of unknown size cannot be replaced with zeros
+		r.offset += uint64(frame.DecompressedSize)
+	default:
+		return 0, frameErr
+	}
+	r.keep(frame, sizeKnown, false)
+	return 0, nil
+}
+
+// keep records the frame and moves the position of the next frame after it.
+func (r *Reader) keep(frame Frame, sizeKnown, checksumKnown bool) {
+	if r.validate {
+		r.frames = append(r.frames, streamFrame{TableEntry: frame.TableEntry, sizeKnown: sizeKnown, checksumKnown: checksumKnown})
+	}
+	r.archiveFrames++
+	r.next.EntryIndex++
+	r.next.EntryOffsetInCompressed += uint64(frame.CompressedSize)
+	r.next.EntryOffsetInDecompressed += uint64(frame.DecompressedSize)
+}
+
+// skippableFrame handles the skippable frame in r.compressed. Seek tables are recognized, other frames such as
+// trailers are ignored. A seek table at the start of the stream whose first entry describes the table itself is
+// the head seek table; a seek table at the start of a following archive is counted as its first frame.
+// Any other seek table ends an archive, and the frames read since the previous one are checked against it.
+func (r *Reader) skippableFrame(frame Frame) error {
+	if binary.LittleEndian.Uint32(r.compressed) != seekTableMagicNumber {
+		return nil
+	}
+	table, err := seektable.ParseTable(r.compressed)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to parse seek table at offset %d", frame.EntryOffsetInCompressed), err)
+	}
+
+	// The head seek table describes itself in its first entry
+	frame.CompressedSize = uint32(len(r.compressed))
+	if r.archiveFrames == 0 && table.NumEntries() > 0 && table.GetEntry(0).CompressedSize == frame.CompressedSize && table.GetEntry(0).DecompressedSize == 0 {
+		if r.next.EntryIndex == 0 {
+			r.table = table
+		}
+		frame.TableEntry = table.GetEntry(0)
+		r.keep(frame, true, false)
+		return nil
+	}
+
+	frames := r.frames
+	r.frames = r.frames[:0]
+	r.archiveFrames = 0
+	if !r.validate {
+		return nil
+	}
+	return checkStreamFrames(frames, table, frame.EntryOffsetInCompressed)
+}
+
+// checkStreamFrames compares frames read from the stream with the seek table that follows them.
+func checkStreamFrames(frames []streamFrame, table *seektable.Table, tableOffset uint64) error {
+	if len(frames) != table.NumEntries() {
+		return errors.Join(ErrSeekTableMismatch, fmt.Errorf("seek table at offset %d describes %d frames, %d frames were read", tableOffset, table.NumEntries(), len(frames)))
+	}
+	for i, frame := range frames {
+		expected := Frame{TableOffset: table.OffsetsByIndex(i), TableEntry: table.GetEntry(i)}
+		var err error
+		switch {
+		case frame.CompressedSize != expected.CompressedSize:
+			err = fmt.Errorf("frame has %d bytes, seek table entry %d", frame.CompressedSize, expected.CompressedSize)
+		case frame.sizeKnown && frame.DecompressedSize != expected.DecompressedSize:
+			err = errors.Join(ErrFrameSizeMismatch, fmt.Errorf("decompressed %d bytes, expected %d", frame.DecompressedSize, expected.DecompressedSize))
+		case frame.checksumKnown && table.HasChecksums() && frame.Checksum != expected.Checksum:
+			err = errors.Join(ErrChecksumMismatch, fmt.Errorf("checksum %08x, expected %08x", frame.Checksum, expected.Checksum))
+		}
+		if err != nil {
+			return &FrameError{Frame: expected, Err: errors.Join(ErrSeekTableMismatch, err)}
+		}
+	}
+	return nil
+}
+
+func (r *Reader) Close() error {
+	r.decoder.Close()
+	if r.unsizedDecoder != nil {
+		r.unsizedDecoder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package szstd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// streamOnly hides all methods of the reader except Read, like a pipe.
+type streamOnly struct {
+	r io.Reader
+}
+
+func (s streamOnly) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+func TestHeadSeekTable(t *testing.T) {
+	data := generateTestData(100*1000+7, 45)
+	for _, concurrency := range []int{1, 4} {
+		for _, checksums := range []bool{false, true} {
+			t.Run(fmt.Sprintf("concurrency=%d/checksums=%v", concurrency, checksums), func(t *testing.T) {
+				tempDir := t.TempDir()
+				compressed := compressTestData(t, data, 1000,
+					WithHeadSeekTable(tempDir), WithWriterConcurrency(concurrency), WithChecksums(checksums))
+				if files, err := os.ReadDir(tempDir); err != nil || len(files) != 0 {
+					t.Fatalf("temporary files are left: %v %v", files, err)
+				}
+
+				// Regular readers see the head seek table as an empty frame
+				reader, err := NewReadSeeker(bytes.NewReader(compressed))
+				if err != nil {
+					t.Fatalf("failed to create reader: %v", err)
+				}
+				defer reader.Close()
+				if reader.NumFrames() != 102 || reader.Frame(0).DecompressedSize != 0 {
+					t.Fatalf("unexpected frames: %d, first %+v", reader.NumFrames(), reader.Frame(0))
+				}
+				decompressed, err := io.ReadAll(reader)
+				if err != nil || !bytes.Equal(decompressed, data) {
+					t.Fatalf("failed to read data with seekable reader: %v", err)
+				}
+				if report, err := Verify(bytes.NewReader(compressed), 0); err != nil || !report.OK() {
+					t.Fatalf("verification failed: %+v %v", report, err)
+				}
+
+				stream, err := NewReader(streamOnly{bytes.NewReader(compressed)})
+				if err != nil {
+					t.Fatalf("failed to create stream reader: %v", err)
+				}
+				defer stream.Close()
+				if stream.Size() != int64(len(data)) || stream.Table().NumEntries() != 102 {
+					t.Fatalf("unexpected size %d and %d entries", stream.Size(), stream.Table().NumEntries())
+				}
+				decompressed, err = io.ReadAll(stream)
+				if err != nil || !bytes.Equal(decompressed, data) {
+					t.Fatalf("failed to read data with stream reader: %v", err)
+				}
+				if stream.Offset() != int64(len(data)) {
+					t.Fatalf("unexpected offset %d after reading everything", stream.Offset())
+				}
+			})
+		}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		// The only entry is the head seek table, which ends at the end of the data
+		compressed := compressTestData(t, nil, 1000, WithHeadSeekTable(t.TempDir()))
+		reader, err := NewReadSeeker(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+		defer reader.Close()
+		if decompressed, err := io.ReadAll(reader); err != nil || len(decompressed) != 0 || reader.NumFrames() != 1 {
+			t.Fatalf("failed to read empty archive of %d frames: %v", reader.NumFrames(), err)
+		}
+		stream, err := NewReader(streamOnly{bytes.NewReader(compressed)})
+		if err != nil {
+			t.Fatalf("failed to create stream reader: %v", err)
+		}
+		defer stream.Close()
+		if decompressed, err := io.ReadAll(stream); err != nil || len(decompressed) != 0 {
+			t.Fatalf("failed to read empty archive with stream reader: %v", err)
+		}
+		if report, err := Verify(bytes.NewReader(compressed), 0); err != nil || !report.OK() {
+			t.Fatalf("verification failed: %+v %v", report, err)
+		}
+	})
+}
+
+func TestReaderSkip(t *testing.T) {
+	data := generateTestData(20*1000, 46)
+	compressed := compressTestData(t, data, 1000, WithHeadSeekTable(t.TempDir()))
+
+	// Frames 6 to 8 (entries 7 to 9) are damaged, but never decoded
+	archive, err := NewReadSeeker(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	damaged := bytes.Clone(compressed)
+	for i := 7; i <= 9; i++ {
+		frame := archive.Frame(i).CompressedRange()
+		clear(damaged[frame.Start+4 : frame.End])
+	}
+
+	stream, err := NewReader(streamOnly{bytes.NewReader(damaged)})
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer stream.Close()
+
+	buffer := make([]byte, 300)
+	if _, err := io.ReadFull(stream, buffer); err != nil || !bytes.Equal(buffer, data[:300]) {
+		t.Fatalf("failed to read start: %v", err)
+	}
+	if skipped, err := stream.Skip(9000 - 300); err != nil || skipped != 8700 {
+		t.Fatalf("failed to skip: %d %v", skipped, err)
+	}
+	if skipped, err := stream.Skip(500); err != nil || skipped != 500 { // inside a frame
+		t.Fatalf("failed to skip: %d %v", skipped, err)
+	}
+	if _, err := io.ReadFull(stream, buffer); err != nil || !bytes.Equal(buffer, data[9500:9800]) {
+		t.Fatalf("failed to read after skipping: %v", err)
+	}
+	if skipped, err := stream.Skip(1 << 20); !errors.Is(err, io.EOF) || skipped != int64(len(data))-9800 {
+		t.Fatalf("expected EOF after skipping %d, got %d %v", len(data)-9800, skipped, err)
+	}
+
+	// Reading the damaged frames fails, unless they are replaced
+	stream, err = NewReader(bytes.NewReader(damaged))
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer stream.Close()
+	var frameErr *FrameError
+	if _, err := io.ReadAll(stream); !errors.As(err, &frameErr) || frameErr.EntryIndex != 7 {
+		t.Fatalf("expected error of frame 7, got %v", err)
+	}
+
+	stream, err = NewReader(bytes.NewReader(damaged), WithCorruptFrameHandler(func(err *FrameError) CorruptFrameAction {
+		return CorruptFrameZeroFill
+	}))
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer stream.Close()
+	decompressed, err := io.ReadAll(stream)
+	expected := bytes.Clone(data)
+	clear(expected[6000:9000])
+	if err != nil || !bytes.Equal(decompressed, expected) {
+		t.Fatalf("failed to read with zero filled frames: %v", err)
+	}
+}
+
+func TestReaderWithoutHeadSeekTable(t *testing.T) {
+	first, second := generateTestData(10*1000+5, 47), generateTestData(3000, 48)
+	firstArchive := compressTestData(t, first, 1000, WithChecksums(true))
+	tarIndex := bytes.NewBuffer(nil) // archive with a trailer between frames and seek table
+	tarWriter, err := NewTarWriter(tarIndex, 1000)
+	if err != nil {
+		t.Fatalf("failed to create tar writer: %v", err)
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	stream := slices.Concat(firstArchive, tarIndex.Bytes(), compressTestData(t, second, 1000))
+
+	for _, validate := range []bool{false, true} {
+		reader, err := NewReader(streamOnly{bytes.NewReader(stream)}, WithSeekTableValidation(validate))
+		if err != nil {
+			t.Fatalf("failed to create stream reader: %v", err)
+		}
+		defer reader.Close()
+		if reader.Table() != nil || reader.Size() != -1 {
+			t.Fatalf("unexpected head seek table with size %d", reader.Size())
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil || !bytes.Equal(decompressed, slices.Concat(first, make([]byte, 1024), second)) { // tar footer
+			t.Fatalf("failed to read concatenated archives: %v", err)
+		}
+	}
+
+	// Seek table describing other frames
+	damaged := bytes.Clone(firstArchive)
+	tableStart := len(damaged) - (8 + 11*12 + 9)
+	damaged[tableStart+8+2*12+4]++ // decompressed size of the third frame
+	reader, err := NewReader(bytes.NewReader(damaged), WithSeekTableValidation(true))
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer reader.Close()
+	var frameErr *FrameError
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrSeekTableMismatch) || !errors.As(err, &frameErr) || frameErr.EntryIndex != 2 {
+		t.Fatalf("expected seek table mismatch of frame 2, got %v", err)
+	}
+
+	// Frames of known size are skipped without decoding
+	archive, err := NewReadSeeker(bytes.NewReader(firstArchive))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	damaged = bytes.Clone(firstArchive)
+	frame := archive.Frame(3).CompressedRange()
+	clear(damaged[frame.Start+12 : frame.End]) // keep frame and block headers
+	reader, err = NewReader(streamOnly{bytes.NewReader(damaged)}, WithSeekTableValidation(true))
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer reader.Close()
+	if skipped, err := reader.Skip(4500); err != nil || skipped != 4500 {
+		t.Fatalf("failed to skip: %d %v", skipped, err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(decompressed, first[4500:]) {
+		t.Fatalf("failed to read after skipping: %v", err)
+	}
+
+	// Archive without frames starts with its seek table
+	reader, err = NewReader(bytes.NewReader(compressTestData(t, nil, 1000)), WithSeekTableValidation(true))
+	if err != nil {
+		t.Fatalf("failed to create stream reader: %v", err)
+	}
+	defer reader.Close()
+	if n, err := reader.Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF, got %d %v", n, err)
+	}
+}
+
+func TestReaderSkippableFrameSize(t *testing.T) {
+	// The first frame may already be read to look for a head seek table
+	readStream := func(stream []byte, opts ...ReaderOption) error {
+		reader, err := NewReader(streamOnly{bytes.NewReader(stream)}, opts...)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		_, err = io.ReadAll(reader)
+		return err
+	}
+
+	for _, magic := range []uint32{0x184D2A50, seekTableMagicNumber} {
+		t.Run(fmt.Sprintf("magic=%08x", magic), func(t *testing.T) {
+			// Only the header of a frame declaring almost 4 GiB
+			stream := binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, magic), 0xFFFFFFF0)
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			if err := readStream(stream); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("expected unexpected EOF, got %v", err)
+			}
+			runtime.ReadMemStats(&after)
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16*1024*1024 {
+				t.Fatalf("reader allocated %d bytes for a stream of %d bytes", allocated, len(stream))
+			}
+		})
+	}
+
+	stream := binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, seekTableMagicNumber), 2000)
+	if err := readStream(stream, WithMaxFrameSize(1000)); !errors.Is(err, ErrFrameSizeLimit) {
+		t.Fatalf("expected frame size limit error, got %v", err)
+	}
+}
+
+func TestReaderFrameLimits(t *testing.T) {
+	t.Run("scanned frame", func(t *testing.T) {
+		// Frame without declared size whose raw blocks of 128 KiB never end
+		header := []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00, 0x68}
+		block := binary.LittleEndian.AppendUint32(nil, maxBlockSize<<3)[:3]
+		block = append(block, make([]byte, maxBlockSize)...)
+		blocks := make([]io.Reader, 512)
+		for i := range blocks {
+			blocks[i] = bytes.NewReader(block)
+		}
+		src := &countingReader{r: io.MultiReader(append([]io.Reader{bytes.NewReader(header)}, blocks...)...)}
+
+		reader, err := NewReader(src, WithMaxFrameSize(1024*1024))
+		if err != nil {
+			t.Fatalf("failed to create stream reader: %v", err)
+		}
+		defer reader.Close()
+		if _, err := io.ReadAll(reader); !errors.Is(err, ErrFrameSizeLimit) {
+			t.Fatalf("expected frame size limit error, got %v", err)
+		}
+		if src.n > 2*1024*1024 {
+			t.Fatalf("read %d bytes of a frame over the limit", src.n)
+		}
+	})
+
+	t.Run("declared size", func(t *testing.T) {
+		// The head seek table declares 10 bytes for a frame of 64 MiB
+		archive := compressTestData(t, make([]byte, 64*1024*1024), 64*1024*1024, WithHeadSeekTable(t.TempDir()))
+		binary.LittleEndian.PutUint32(archive[8+8+4:], 10) // decompressed size of entry 1
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		reader, err := NewReader(streamOnly{bytes.NewReader(archive)})
+		if err != nil {
+			t.Fatalf("failed to create stream reader: %v", err)
+		}
+		defer reader.Close()
+		if _, err := io.ReadAll(reader); !errors.Is(err, ErrFrameSizeMismatch) {
+			t.Fatalf("expected frame size mismatch, got %v", err)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16*1024*1024 {
+			t.Fatalf("reader allocated %d bytes for a frame of 10 bytes", allocated)
+		}
+	})
+}
+
+func TestConcatDropsHeadSeekTables(t *testing.T) {
+	first, second := generateTestData(3000, 48), generateTestData(2000, 49)
+	combined := bytes.NewBuffer(nil)
+	err := Concat(combined,
+		bytes.NewReader(compressTestData(t, first, 1000, WithHeadSeekTable(t.TempDir()))),
+		bytes.NewReader(compressTestData(t, second, 1000, WithHeadSeekTable(t.TempDir()))))
+	if err != nil {
+		t.Fatalf("failed to concatenate archives: %v", err)
+	}
+
+	reader, err := NewReadSeeker(bytes.NewReader(combined.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if reader.NumFrames() != 5 {
+		t.Fatalf("expected 5 frames, got %d", reader.NumFrames())
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(decompressed, append(first, second...)) {
+		t.Fatalf("failed to read combined data: %v", err)
+	}
+	if stream, err := NewReader(bytes.NewReader(combined.Bytes())); err != nil || stream.Table() != nil {
+		t.Fatalf("expected no head seek table: %v", err)
+	}
+}
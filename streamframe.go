@@ -0,0 +1,74 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+// writeStreaming compresses data with the streaming encoder straight into the output, starting a new frame when
+// the current one reaches the frame size. See `WithStreamingFrames`.
+func (c *Writer) writeStreaming(data []byte) (int, error) {
+	n := 0
+	for len(data) > 0 {
+		if !c.streamOpen {
+			if err := c.checkFrameLimit(); err != nil {
+				return n, errors.Join(errors.New("error while writing frame"), err)
+			}
+			c.streamOut = countingWriter{w: c.w}
+			c.encoder.Reset(&c.streamOut)
+			if c.streamDigest != nil {
+				c.streamDigest.Reset()
+			}
+			c.streamOpen = true
+		}
+
+		chunk := data[:min(len(data), c.frameSize-c.streamSize)]
+		written, err := c.encoder.Write(chunk)
+		if c.streamDigest != nil {
+			c.streamDigest.Write(chunk[:written])
+		}
+		c.streamSize += written
+		n += written
+		data = data[written:]
+		if err != nil {
+			return n, errors.Join(errors.New("error while writing frame"), err)
+		}
+
+		if c.streamSize == c.frameSize {
+			if err := c.endStreamFrame(); err != nil {
+				return n, errors.Join(errors.New("error while writing frame"), err)
+			}
+		}
+	}
+	return n, nil
+}
+
+// endStreamFrame finishes the frame of the streaming encoder, if one is started, and records it in the seek table.
+func (c *Writer) endStreamFrame() error {
+	if !c.streamOpen {
+		return nil
+	}
+	c.streamOpen = false
+	size := c.streamSize
+	c.streamSize = 0
+
+	if err := c.encoder.Close(); err != nil {
+		return err
+	}
+	if c.streamOut.n > math.MaxUint32 {
+		return fmt.Errorf("%w: compressed frame has %d bytes", ErrFrameTooLarge, c.streamOut.n)
+	}
+	entry := seektable.TableEntry{
+		DecompressedSize: uint32(size),
+		CompressedSize:   uint32(c.streamOut.n),
+	}
+	if c.streamDigest != nil {
+		entry.Checksum = uint32(c.streamDigest.Sum64()) // same as seektable.Checksum of the frame data
+	}
+	c.seekTable.AppendEntry(entry)
+	c.numFrames++
+	return nil
+}
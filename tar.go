@@ -0,0 +1,158 @@
+package szstd
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var tarIndexKind = [4]byte{'T', 'I', 'D', 'X'}
+
+var ErrInvalidTarIndex = errors.New("invalid tar member index")
+
+// TarMember describes where a tar member is located in the decompressed data of the archive.
+type TarMember struct {
+	Name         string
+	Typeflag     byte
+	HeaderOffset int64 // offset of the first header block of the member. Always the start of a frame
+	Offset       int64 // offset of the member content
+	Size         int64 // size of the member content
+}
+
+// TarWriter writes a tar stream into a seekable zstd archive.
+// Every member header starts a new frame, so extracting a single member only decompresses frames of that member.
+// Large members are split into frames of the configured frame size. On `Close` a member index is written
+// into a skippable frame right before the seek table. The result is a regular `.tar.zst` file.
+type TarWriter struct {
+	zw *Writer
+	cw countingWriter
+	tw *tar.Writer
+
+	members []TarMember
+}
+
+func NewTarWriter(w io.Writer, frameSize int, opts ...WriterOption) (*TarWriter, error) {
+	zw, err := NewWriterWithOptions(w, frameSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TarWriter{zw: zw}
+	t.cw.w = zw
+	t.tw = tar.NewWriter(&t.cw)
+	return t, nil
+}
+
+// WriteHeader finishes the current member and starts a new one in a new frame. See `tar.Writer.WriteHeader`.
+func (t *TarWriter) WriteHeader(hdr *tar.Header) error {
+	// Padding of the previous member belongs to the previous frame
+	if err := t.tw.Flush(); err != nil {
+		return err
+	}
+	if err := t.zw.Flush(); err != nil {
+		return errors.Join(errors.New("failed to finish frame before tar header"), err)
+	}
+
+	headerOffset := t.cw.n
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	t.members = append(t.members, TarMember{
+		Name:         hdr.Name,
+		Typeflag:     hdr.Typeflag,
+		HeaderOffset: headerOffset,
+		Offset:       t.cw.n,
+		Size:         hdr.Size,
+	})
+	return nil
+}
+
+// Write writes content of the current member. See `tar.Writer.Write`.
+func (t *TarWriter) Write(p []byte) (int, error) {
+	return t.tw.Write(p)
+}
+
+// Members returns index of the members written so far.
+func (t *TarWriter) Members() []TarMember {
+	return t.members
+}
+
+// Close writes the tar footer, the member index and the seek table.
+func (t *TarWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return errors.Join(errors.New("failed to close tar writer"), err)
+	}
+	t.zw.trailers = append(t.zw.trailers, appendTrailerFrame(nil, tarIndexKind, encodeTarIndex(t.members)))
+	return t.zw.Close()
+}
+
+// ReadTarIndex reads the member index written by `TarWriter`.
+// Returns `ErrTrailerNotFound` if the archive does not contain an index.
+func ReadTarIndex(r io.ReadSeeker) ([]TarMember, error) {
+	payload, err := readTrailer(r, tarIndexKind)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTarIndex(payload)
+}
+
+// Index layout: number of members (4 bytes), then for every member
+// header offset (8 bytes), content offset (8 bytes), size (8 bytes), typeflag (1 byte), name length (4 bytes), name.
+func encodeTarIndex(members []TarMember) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, uint32(len(members)))
+	for _, m := range members {
+		data = binary.LittleEndian.AppendUint64(data, uint64(m.HeaderOffset))
+		data = binary.LittleEndian.AppendUint64(data, uint64(m.Offset))
+		data = binary.LittleEndian.AppendUint64(data, uint64(m.Size))
+		data = append(data, m.Typeflag)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(m.Name)))
+		data = append(data, m.Name...)
+	}
+	return data
+}
+
+func decodeTarIndex(data []byte) ([]TarMember, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidTarIndex
+	}
+	count := binary.LittleEndian.Uint32(data[0:4])
+	data = data[4:]
+	if uint64(count) > uint64(len(data))/29 { // every member takes at least 29 bytes
+		return nil, errors.Join(ErrInvalidTarIndex, fmt.Errorf("index declares %d members in %d bytes", count, len(data)))
+	}
+
+	members := make([]TarMember, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(data) < 29 {
+			return nil, errors.Join(ErrInvalidTarIndex, fmt.Errorf("member %d is truncated", i))
+		}
+		m := TarMember{
+			HeaderOffset: int64(binary.LittleEndian.Uint64(data[0:8])),
+			Offset:       int64(binary.LittleEndian.Uint64(data[8:16])),
+			Size:         int64(binary.LittleEndian.Uint64(data[16:24])),
+			Typeflag:     data[24],
+		}
+		nameLength := binary.LittleEndian.Uint32(data[25:29])
+		data = data[29:]
+		if uint64(nameLength) > uint64(len(data)) {
+			return nil, errors.Join(ErrInvalidTarIndex, fmt.Errorf("member %d name is truncated", i))
+		}
+		m.Name = string(data[:nameLength])
+		data = data[nameLength:]
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,122 @@
+package szstd
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+func TestTarWriter(t *testing.T) {
+	const frameSize = 16 * 1024
+	contents := map[string][]byte{
+		"empty.txt":  {},
+		"small.txt":  generateTestData(100, 1),
+		"medium.txt": generateTestData(10*1024, 2),
+		"large.bin":  generateTestData(5*frameSize+123, 3),
+	}
+	names := []string{"dir/", "empty.txt", "small.txt", "large.bin", "medium.txt"}
+
+	compressed := bytes.NewBuffer(nil)
+	tw, err := NewTarWriter(compressed, frameSize)
+	if err != nil {
+		t.Fatalf("failed to create tar writer: %v", err)
+	}
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents[name])), Typeflag: tar.TypeReg}
+		if name == "dir/" {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0o755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write header of %s: %v", name, err)
+		}
+		if _, err := tw.Write(contents[name]); err != nil {
+			t.Fatalf("failed to write content of %s: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+
+	// Standard tools must be able to extract the archive
+	decoder, err := zstd.NewReader(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create zstd reader: %v", err)
+	}
+	defer decoder.Close()
+	tr := tar.NewReader(decoder)
+	for _, name := range names {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("failed to read tar header: %v", err)
+		}
+		if hdr.Name != name {
+			t.Fatalf("unexpected member %q, expected %q", hdr.Name, name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read member %s: %v", name, err)
+		}
+		if !bytes.Equal(content, contents[name]) {
+			t.Fatalf("content of member %s does not match", name)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Fatalf("expected end of tar archive, got %v", err)
+	}
+
+	// Every member must start a frame and be readable through the index
+	members, err := ReadTarIndex(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read tar index: %v", err)
+	}
+	if len(members) != len(names) {
+		t.Fatalf("index has %d members, expected %d", len(members), len(names))
+	}
+	table, err := seektable.ReadTableFromReadSeeker(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read seek table: %v", err)
+	}
+	reader, err := NewReadSeeker(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create szstd reader: %v", err)
+	}
+	defer reader.Close()
+	for i, member := range members {
+		if member.Name != names[i] {
+			t.Fatalf("index member %d is %q, expected %q", i, member.Name, names[i])
+		}
+		frame, found := table.Find(uint64(member.HeaderOffset))
+		if !found || frame.EntryOffsetInDecompressed != uint64(member.HeaderOffset) {
+			t.Fatalf("member %s header at %d does not start a frame", member.Name, member.HeaderOffset)
+		}
+		if _, err := reader.Seek(member.Offset, io.SeekStart); err != nil {
+			t.Fatalf("failed to seek to member %s: %v", member.Name, err)
+		}
+		content := make([]byte, member.Size)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			t.Fatalf("failed to read member %s: %v", member.Name, err)
+		}
+		if !bytes.Equal(content, contents[member.Name]) {
+			t.Fatalf("content of member %s read through index does not match", member.Name)
+		}
+	}
+
+	// Large member is split by frame size
+	for i := 0; i < table.NumEntries(); i++ {
+		if size := table.GetEntry(i).DecompressedSize; size > frameSize {
+			t.Fatalf("frame %d has %d bytes, expected at most %d", i, size, frameSize)
+		}
+	}
+}
+
+func TestReadTarIndexMissing(t *testing.T) {
+	compressed := compressTestData(t, generateTestData(1000, 4), 256)
+	if _, err := ReadTarIndex(bytes.NewReader(compressed)); err != ErrTrailerNotFound {
+		t.Fatalf("expected ErrTrailerNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package szstd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// TranscodeStats is the result of `Transcode`.
+type TranscodeStats struct {
+	ReusedFrames     int // source frames copied verbatim
+	ReencodedFrames  int // source frames decoded and compressed again
+	DecompressedSize uint64
+}
+
+// TranscodeOption is an option for `Transcode` and `Slice`.
+type TranscodeOption func(*transcodeOptions) error
+
+type transcodeOptions struct {
+	readerOptions []ReaderOption
+	writerOptions []WriterOption
+}
+
+// WithSourceOptions sets reader options of the source archive, for example its dictionary through
+// `WithDecoderOptions`, or limits of an untrusted archive.
+func WithSourceOptions(opts ...ReaderOption) TranscodeOption {
+	return func(o *transcodeOptions) error {
+		o.readerOptions = append(o.readerOptions, opts...)
+		return nil
+	}
+}
+
+// WithTargetOptions sets writer options of the new archive, such as compression level, dictionary or checksums.
+// The writer concurrency is also used to decode source frames.
+func WithTargetOptions(opts ...WriterOption) TranscodeOption {
+	return func(o *transcodeOptions) error {
+		o.writerOptions = append(o.writerOptions, opts...)
+		return nil
+	}
+}
+
+func applyTranscodeOptions(opts []TranscodeOption) (transcodeOptions, error) {
+	var o transcodeOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, errors.Join(errors.New("invalid transcode option"), err)
+		}
+	}
+	return o, nil
+}
+
+type transcodeJob struct {
+	frame    Frame
+	raw      []byte
+	data     []byte
+	encoded  []byte // data compressed by the target encoder, only for reusable frames
+	reusable bool   // frame boundaries match the target frame size
+	err      error
+}
+
+// Transcode reads the src archive through its seek table and writes its data into dst as a new archive with the
+// given frame size and options. Source frames are decoded in parallel using the writer concurrency and verified
+// against the source seek table.
+//
+// A source frame can be reused when it starts at a multiple of frameSize and holds exactly frameSize bytes (or
+// fewer for the last frame). Without target encoder options and source decoder options the encoding does not change,
+// so such frames are copied verbatim after their data passed the checks, without compressing them again. Otherwise
+// they are compressed with the target encoder, and copied verbatim only if it produces identical bytes, so the output
+// is the same as if all data was written by a new writer. Only decompressed data of frames whose boundaries change has
+// to be buffered by the writer. Trailing skippable frames written by this
+// package, such as the tar index or metadata, are copied to the new archive since decompressed offsets do not change.
+func Transcode(dst io.Writer, src io.ReadSeeker, frameSize int, opts ...TranscodeOption) (*TranscodeStats, error) {
+	to, err := applyTranscodeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	o, err := applyWriterOptions(to.writerOptions)
+	if err != nil {
+		return nil, err
+	}
+	ro, err := applyReaderOptions(to.readerOptions)
+	if err != nil {
+		return nil, err
+	}
+	table, err := seektable.ReadTableFromReadSeeker(src, seektable.WithParseMode(ro.parseMode))
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read seek table"), err)
+	}
+	if err := ro.checkTable(table); err != nil {
+		return nil, err
+	}
+	compare := len(o.encoderOptions) > 0 || len(ro.decoderOptions) > 0 // encoding of reusable frames may change
+	trailers, err := readTrailers(src, table)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read trailing skippable frames"), err)
+	}
+
+	decoders := make([]*zstd.Decoder, o.concurrency)
+	for i := range decoders {
+		if decoders[i], err = newTableDecoder(ro); err != nil {
+			closeDecoders(decoders)
+			return nil, err
+		}
+	}
+	defer closeDecoders(decoders)
+	encoders := make([]*zstd.Encoder, o.concurrency)
+	defer func() {
+		for _, encoder := range encoders {
+			if encoder != nil {
+				encoder.Close()
+			}
+		}
+	}()
+	for i := range encoders {
+		if !compare {
+			break
+		}
+		encoders[i], err = zstd.NewWriter(nil, append([]zstd.EOption{zstd.WithEncoderConcurrency(1)}, o.encoderOptions...)...)
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to create zstd encoder"), err)
+		}
+	}
+
+	writer, err := NewWriterWithOptions(dst, frameSize, to.writerOptions...)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trailers {
+		writer.trailers = append(writer.trailers, appendTrailerFrame(nil, t.kind, t.payload))
+	}
+
+	stats := &TranscodeStats{}
+	buffers := newBufferPool(o.concurrency*9, 0) // raw, data and encoded buffers of every frame in flight
+	frames := newPipeline(o.concurrency, func(worker int, job *transcodeJob) {
+		if job.err = ro.checkFrame(job.frame); job.err != nil {
+			return
+		}
+		if job.data, job.err = decodeEntry(decoders[worker], job.raw, job.frame.TableEntry, job.data); job.err != nil {
+			return
+		}
+		if job.err = checkDecodedFrame(job.frame, job.data, table.HasChecksums()); job.err != nil {
+			return
+		}
+		if job.reusable && compare {
+			job.encoded = encoders[worker].EncodeAll(job.data, job.encoded)
+		}
+	}, func(job *transcodeJob) error {
+		defer buffers.Put(job.raw)
+		defer buffers.Put(job.data)
+		defer buffers.Put(job.encoded)
+		if job.err != nil {
+			return &FrameError{Frame: job.frame, Err: job.err}
+		}
+		if len(job.data) == 0 {
+			return nil // frames without data, such as the head seek table, are not written
+		}
+
+		switch {
+		case job.reusable && (!compare || bytes.Equal(job.encoded, job.raw)):
+			if err := writer.WriteRawFrame(job.raw, frameEntry(job)); err != nil {
+				return err
+			}
+			stats.ReusedFrames++
+		case job.reusable:
+			// The frame is compressed already, as the writer would do it
+			entry := frameEntry(job)
+			entry.CompressedSize = uint32(len(job.encoded))
+			if err := writer.WriteRawFrame(job.encoded, entry); err != nil {
+				return err
+			}
+			stats.ReencodedFrames++
+		default:
+			if _, err := writer.Write(job.data); err != nil {
+				return err
+			}
+			stats.ReencodedFrames++
+		}
+		stats.DecompressedSize += uint64(len(job.data))
+		return nil
+	})
+
+	for i := 0; i < table.NumEntries(); i++ {
+		frame := Frame{TableOffset: table.OffsetsByIndex(i), TableEntry: table.GetEntry(i)}
+		lastFrame := i == table.NumEntries()-1
+		job := transcodeJob{frame: frame, data: buffers.Get(), encoded: buffers.Get()}
+		job.reusable = frame.EntryOffsetInDecompressed%uint64(frameSize) == 0 && frame.DecompressedSize > 0 &&
+			(int64(frame.DecompressedSize) == int64(frameSize) || lastFrame && int64(frame.DecompressedSize) < int64(frameSize))
+		if job.raw, err = readAt(src, frame.EntryOffsetInCompressed, int(frame.CompressedSize), buffers.Get()); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to read frame %d", i), err, frames.Close(), writer.Close())
+		}
+		if err := frames.Submit(job); err != nil {
+			break
+		}
+	}
+	if err := frames.Close(); err != nil {
+		return nil, errors.Join(errors.New("failed to transcode frame"), err, writer.Close())
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// frameEntry returns the seek table entry of a reusable frame, with the checksum of its data.
+func frameEntry(job *transcodeJob) seektable.TableEntry {
+	entry := job.frame.TableEntry
+	entry.Checksum = seektable.Checksum(job.data)
+	return entry
+}
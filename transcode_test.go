@@ -0,0 +1,211 @@
+package szstd
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+func TestTranscode(t *testing.T) {
+	const frameSize = 8 * 1024
+	data := generateTestData(20*frameSize+500, 34)
+	source := compressTestData(t, data, frameSize)
+
+	transcode := func(t *testing.T, src []byte, frameSize int, opts ...WriterOption) ([]byte, *TranscodeStats) {
+		t.Helper()
+		archive := bytes.NewBuffer(nil)
+		stats, err := Transcode(archive, bytes.NewReader(src), frameSize, WithTargetOptions(opts...))
+		if err != nil {
+			t.Fatalf("failed to transcode: %v", err)
+		}
+		report, err := Verify(bytes.NewReader(archive.Bytes()), 2)
+		if err != nil || !report.OK() {
+			t.Fatalf("transcoded archive failed verification: %v %+v", err, report)
+		}
+		reader, err := NewReadSeeker(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open transcoded archive: %v", err)
+		}
+		defer reader.Close()
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read transcoded archive: %v", err)
+		}
+		if !bytes.Equal(decompressed, data) {
+			t.Fatalf("transcoded data does not match")
+		}
+		if stats.DecompressedSize != uint64(len(data)) {
+			t.Fatalf("unexpected decompressed size %d", stats.DecompressedSize)
+		}
+		return archive.Bytes(), stats
+	}
+
+	t.Run("same settings", func(t *testing.T) {
+		archive, stats := transcode(t, source, frameSize, WithWriterConcurrency(4))
+		if stats.ReusedFrames != 21 || stats.ReencodedFrames != 0 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		if !bytes.Equal(archive, source) {
+			t.Fatalf("transcoded archive differs from the source")
+		}
+	})
+
+	t.Run("frame size", func(t *testing.T) {
+		archive, stats := transcode(t, source, 3*frameSize, WithWriterConcurrency(4))
+		if stats.ReusedFrames != 0 || stats.ReencodedFrames != 21 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		info, err := Inspect(bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("failed to inspect transcoded archive: %v", err)
+		}
+		if info.NumFrames != 7 {
+			t.Fatalf("expected 7 frames, got %d", info.NumFrames)
+		}
+	})
+
+	t.Run("level", func(t *testing.T) {
+		_, stats := transcode(t, source, frameSize, WithEncoderOptions(zstd.WithEncoderLevel(zstd.SpeedBestCompression)))
+		if stats.ReusedFrames != 0 || stats.ReencodedFrames != 21 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("mixed levels", func(t *testing.T) {
+		// Only the first frame matches the target encoder, the rest has to be compressed again
+		mixed := bytes.NewBuffer(nil)
+		writer, err := NewWriter(mixed, frameSize)
+		if err != nil {
+			t.Fatalf("failed to create szstd writer: %v", err)
+		}
+		if _, err := writer.Write(data[:frameSize]); err != nil {
+			t.Fatalf("failed to write data: %v", err)
+		}
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		if err != nil {
+			t.Fatalf("failed to create zstd encoder: %v", err)
+		}
+		defer encoder.Close()
+		for offset := frameSize; offset < len(data); offset += frameSize {
+			chunk := data[offset:min(offset+frameSize, len(data))]
+			frame := encoder.EncodeAll(chunk, nil)
+			entry := seektable.TableEntry{CompressedSize: uint32(len(frame)), DecompressedSize: uint32(len(chunk))}
+			if err := writer.WriteRawFrame(frame, entry); err != nil {
+				t.Fatalf("failed to write raw frame: %v", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("failed to close szstd writer: %v", err)
+		}
+
+		// Without encoder options frames are copied without compressing them again
+		archive, stats := transcode(t, mixed.Bytes(), frameSize)
+		if stats.ReusedFrames != 21 || stats.ReencodedFrames != 0 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		if !bytes.Equal(archive, mixed.Bytes()) {
+			t.Fatalf("transcoded archive differs from the source")
+		}
+
+		archive, stats = transcode(t, mixed.Bytes(), frameSize, WithEncoderOptions(zstd.WithEncoderLevel(zstd.SpeedDefault)))
+		if stats.ReusedFrames != 1 || stats.ReencodedFrames != 20 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		if !bytes.Equal(archive, source) {
+			t.Fatalf("transcoded archive differs from a new archive")
+		}
+	})
+
+	t.Run("head seek table", func(t *testing.T) {
+		// The head seek table is dropped and not counted
+		src := compressTestData(t, data, frameSize, WithHeadSeekTable(t.TempDir()))
+		_, stats := transcode(t, src, 3*frameSize)
+		if stats.ReusedFrames != 0 || stats.ReencodedFrames != 21 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		archive, stats := transcode(t, src, frameSize)
+		if stats.ReusedFrames != 21 || stats.ReencodedFrames != 0 || !bytes.Equal(archive, source) {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("source options", func(t *testing.T) {
+		dict := generateTestData(4096, 35)
+		src := compressTestData(t, data, frameSize, WithEncoderOptions(zstd.WithEncoderDictRaw(9, dict)))
+		if _, err := Transcode(io.Discard, bytes.NewReader(src), frameSize); err == nil {
+			t.Fatalf("transcoded an archive with a dictionary without it")
+		}
+		archive := bytes.NewBuffer(nil)
+		if _, err := Transcode(archive, bytes.NewReader(src), frameSize,
+			WithSourceOptions(WithDecoderOptions(zstd.WithDecoderDictRaw(9, dict)))); err != nil {
+			t.Fatalf("failed to transcode: %v", err)
+		}
+		if !bytes.Equal(archive.Bytes(), source) {
+			t.Fatalf("transcoded archive differs from a new archive")
+		}
+
+		if _, err := Transcode(io.Discard, bytes.NewReader(source), frameSize,
+			WithSourceOptions(WithMaxDecompressedSize(1000))); !errors.Is(err, ErrDecompressedSizeLimit) {
+			t.Fatalf("expected decompressed size limit error, got %v", err)
+		}
+	})
+
+	t.Run("add checksums", func(t *testing.T) {
+		archive, stats := transcode(t, source, frameSize, WithChecksums(true))
+		if stats.ReusedFrames != 21 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		report, err := Verify(bytes.NewReader(archive), 1)
+		if err != nil || !report.ChecksumsChecked {
+			t.Fatalf("checksums were not added: %v %+v", err, report)
+		}
+	})
+
+	t.Run("partially aligned", func(t *testing.T) {
+		// Every second source frame starts at a multiple of the target frame size, but only the last frame
+		// may be shorter than a target frame
+		_, stats := transcode(t, source, 2*frameSize)
+		if stats.ReusedFrames != 1 || stats.ReencodedFrames != 20 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		large := compressTestData(t, data, 2*frameSize)
+		_, stats = transcode(t, large, frameSize)
+		if stats.ReusedFrames != 1 || stats.ReencodedFrames != 10 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("tar index", func(t *testing.T) {
+		archive := bytes.NewBuffer(nil)
+		tw, err := NewTarWriter(archive, frameSize)
+		if err != nil {
+			t.Fatalf("failed to create tar writer: %v", err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: "data", Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("failed to write tar member: %v", err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatalf("failed to close tar writer: %v", err)
+		}
+
+		transcoded := bytes.NewBuffer(nil)
+		if _, err := Transcode(transcoded, bytes.NewReader(archive.Bytes()), 4*frameSize); err != nil {
+			t.Fatalf("failed to transcode: %v", err)
+		}
+		members, err := ReadTarIndex(bytes.NewReader(transcoded.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to read tar index of transcoded archive: %v", err)
+		}
+		if len(members) != 1 || members[0].Name != "data" || members[0].Size != int64(len(data)) {
+			t.Fatalf("unexpected tar index %+v", members)
+		}
+	})
+}
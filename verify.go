@@ -0,0 +1,158 @@
+package szstd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/seektable"
+)
+
+// VerifyReport is the result of `Verify`.
+type VerifyReport struct {
+	NumFrames        int
+	CompressedSize   uint64 // size of all frames described by the seek table
+	DecompressedSize uint64
+	ChecksumsChecked bool // seek table has checksums and they were compared with decompressed data
+
+	BadFrames []*FrameError // in frame order
+
+	// Compressed range between the last frame and the seek table that does not consist of skippable frames.
+	// Empty if the archive has no trailing garbage.
+	TrailingGarbage Range
+}
+
+// OK reports whether the archive has no problems.
+func (r *VerifyReport) OK() bool {
+	return len(r.BadFrames) == 0 && r.TrailingGarbage.Len() == 0
+}
+
+type verifyJob struct {
+	frame Frame
+	raw   []byte
+	err   error
+}
+
+// Verify decodes every frame of the archive on `concurrency` goroutines and checks that decompressed sizes and
+// checksums (if present) match the seek table. It also checks that only skippable frames are located between
+// the last frame and the seek table. Problems are collected into the report instead of stopping at the first one.
+// Error is returned only if the seek table cannot be read or the reader fails.
+// Frames exceeding the limits of the reader options are reported as bad without being read, and decoding of a
+// frame stops as soon as it exceeds its seek table entry. If concurrency is 0, GOMAXPROCS is used.
+func Verify(r io.ReadSeeker, concurrency int, opts ...ReaderOption) (*VerifyReport, error) {
+	o, err := applyReaderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 0 {
+		return nil, errors.New("concurrency must not be negative")
+	}
+	if concurrency == 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	table, err := seektable.ReadTableFromReadSeeker(r, seektable.WithParseMode(o.parseMode))
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read seek table"), err)
+	}
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to seek to end of data"), err)
+	}
+	dataEnd := uint64(fileSize) - uint64(table.Size())
+
+	decoders := make([]*zstd.Decoder, concurrency)
+	decoded := make([][]byte, concurrency)
+	for i := range decoders {
+		decoders[i], err = newTableDecoder(o)
+		if err != nil {
+			closeDecoders(decoders)
+			return nil, err
+		}
+	}
+	defer closeDecoders(decoders)
+
+	report := &VerifyReport{
+		NumFrames:        table.NumEntries(),
+		ChecksumsChecked: table.HasChecksums(),
+	}
+	buffers := newBufferPool(concurrency*3, 0)
+	frames := newPipeline(concurrency, func(worker int, job *verifyJob) {
+		if job.err != nil {
+			return
+		}
+		decoded[worker], job.err = decodeEntry(decoders[worker], job.raw, job.frame.TableEntry, decoded[worker][:0])
+		if job.err == nil {
+			job.err = checkDecodedFrame(job.frame, decoded[worker], table.HasChecksums())
+		}
+	}, func(job *verifyJob) error {
+		buffers.Put(job.raw)
+		if job.err != nil {
+			report.BadFrames = append(report.BadFrames, &FrameError{Frame: job.frame, Err: job.err})
+		}
+		return nil
+	})
+
+	for i := 0; i < table.NumEntries(); i++ {
+		job := verifyJob{frame: Frame{TableOffset: table.OffsetsByIndex(i), TableEntry: table.GetEntry(i)}}
+		report.CompressedSize += uint64(job.frame.CompressedSize)
+		report.DecompressedSize += uint64(job.frame.DecompressedSize)
+
+		if err := o.checkFrame(job.frame); err != nil {
+			job.err = err // not read, like frames rejected by `ReadSeeker`
+		} else if job.frame.CompressedRange().End > dataEnd {
+			job.err = ErrFrameOutOfBounds
+		} else if job.raw, err = readAt(r, job.frame.EntryOffsetInCompressed, int(job.frame.CompressedSize), buffers.Get()); err != nil {
+			frames.Close()
+			return nil, errors.Join(fmt.Errorf("failed to read frame %d", i), err)
+		}
+		frames.Submit(job)
+	}
+	frames.Close()
+
+	if framesEnd := framesEnd(table); framesEnd < dataEnd {
+		_, stop, err := scanSkippableFrames(r, int64(framesEnd), int64(dataEnd))
+		if err != nil {
+			return nil, err
+		}
+		if uint64(stop) < dataEnd {
+			report.TrailingGarbage = Range{Start: uint64(stop), End: dataEnd}
+		}
+	}
+
+	return report, nil
+}
+
+// checkDecodedFrame compares decompressed frame data with its seek table entry.
+func checkDecodedFrame(frame Frame, data []byte, checksums bool) error {
+	if len(data) != int(frame.DecompressedSize) {
+		return errors.Join(ErrFrameSizeMismatch, fmt.Errorf("decompressed %d bytes, expected %d", len(data), frame.DecompressedSize))
+	}
+	if checksums {
+		if checksum := seektable.Checksum(data); checksum != frame.Checksum {
+			return errors.Join(ErrChecksumMismatch, fmt.Errorf("checksum %08x, expected %08x", checksum, frame.Checksum))
+		}
+	}
+	return nil
+}
+
+// readAt reads size bytes at the offset into dst, reusing its capacity.
+func readAt(r io.ReadSeeker, offset uint64, size int, dst []byte) ([]byte, error) {
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return dst, err
+	}
+	dst = slices.Grow(dst[:0], size)[:size]
+	_, err := io.ReadFull(r, dst)
+	return dst, err
+}
+
+func closeDecoders(decoders []*zstd.Decoder) {
+	for _, decoder := range decoders {
+		if decoder != nil {
+			decoder.Close()
+		}
+	}
+}
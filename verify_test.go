@@ -0,0 +1,149 @@
+package szstd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+func TestVerify(t *testing.T) {
+	const frameSize = 8 * 1024
+	data := generateTestData(16*frameSize, 8)
+	healthy := compressTestData(t, data, frameSize, WithChecksums(true))
+
+	readTable := func(archive []byte) *seektable.Table {
+		table, err := seektable.ReadTableFromReadSeeker(bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("failed to read seek table: %v", err)
+		}
+		return table
+	}
+	table := readTable(healthy)
+	tableStart := len(healthy) - table.Size()
+
+	verify := func(archive []byte) *VerifyReport {
+		report, err := Verify(bytes.NewReader(archive), 4)
+		if err != nil {
+			t.Fatalf("failed to verify archive: %v", err)
+		}
+		return report
+	}
+	badFrames := func(report *VerifyReport) []int {
+		var indexes []int
+		for _, frame := range report.BadFrames {
+			indexes = append(indexes, frame.EntryIndex)
+		}
+		return indexes
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		report := verify(healthy)
+		if !report.OK() || !report.ChecksumsChecked || report.NumFrames != 16 || report.DecompressedSize != uint64(len(data)) {
+			t.Fatalf("unexpected report for healthy archive: %+v", report)
+		}
+	})
+
+	t.Run("corrupted frames", func(t *testing.T) {
+		archive := slices.Clone(healthy)
+		for _, index := range []int{3, 7} {
+			frame := table.OffsetsByIndex(index)
+			for i := range 32 {
+				archive[frame.EntryOffsetInCompressed+20+uint64(i)] ^= 0x55
+			}
+		}
+		report := verify(archive)
+		if got := badFrames(report); !slices.Equal(got, []int{3, 7}) {
+			t.Fatalf("expected bad frames [3 7], got %v", got)
+		}
+		bad := report.BadFrames[0]
+		if bad.DecompressedRange() != (Range{Start: 3 * frameSize, End: 4 * frameSize}) {
+			t.Fatalf("unexpected decompressed range %+v", bad.DecompressedRange())
+		}
+		if bad.CompressedRange().Start != table.OffsetsByIndex(3).EntryOffsetInCompressed {
+			t.Fatalf("unexpected compressed range %+v", bad.CompressedRange())
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		archive := slices.Clone(healthy)
+		checksumOffset := tableStart + 8 + 5*12 + 8 // header + 5 entries + sizes of the 6th entry
+		binary.LittleEndian.PutUint32(archive[checksumOffset:], 0xDEADBEEF)
+		report := verify(archive)
+		if got := badFrames(report); !slices.Equal(got, []int{5}) || !errors.Is(report.BadFrames[0].Err, ErrChecksumMismatch) {
+			t.Fatalf("expected checksum mismatch in frame 5, got %+v", report.BadFrames)
+		}
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		archive := slices.Clone(healthy)
+		sizeOffset := tableStart + 8 + 2*12 + 4 // decompressed size of the 3rd entry
+		binary.LittleEndian.PutUint32(archive[sizeOffset:], frameSize+1)
+		report := verify(archive)
+		if got := badFrames(report); !slices.Equal(got, []int{2}) || !errors.Is(report.BadFrames[0].Err, ErrFrameSizeMismatch) {
+			t.Fatalf("expected size mismatch in frame 2, got %+v", report.BadFrames)
+		}
+	})
+
+	t.Run("trailing garbage", func(t *testing.T) {
+		archive := slices.Concat(healthy[:tableStart], appendTrailerFrame(nil, [4]byte{'T', 'E', 'S', 'T'}, []byte("payload")), []byte("garbage"), healthy[tableStart:])
+		report := verify(archive)
+		garbageStart := uint64(tableStart + 8 + 4 + len("payload"))
+		if len(report.BadFrames) != 0 || report.TrailingGarbage != (Range{Start: garbageStart, End: garbageStart + 7}) {
+			t.Fatalf("unexpected report for archive with trailing garbage: %+v", report)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		last := table.OffsetsByIndex(15).EntryOffsetInCompressed
+		archive := slices.Concat(healthy[:last+10], healthy[tableStart:])
+		report := verify(archive)
+		if got := badFrames(report); !slices.Equal(got, []int{15}) || !errors.Is(report.BadFrames[0].Err, ErrFrameOutOfBounds) {
+			t.Fatalf("expected frame 15 out of bounds, got %+v", report.BadFrames)
+		}
+	})
+}
+
+func TestVerifyLimits(t *testing.T) {
+	t.Run("compression bomb", func(t *testing.T) {
+		archive := compressionBombArchive(t, 4096) // 16 KiB decompressing to 512 MiB
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		report, err := Verify(bytes.NewReader(archive), 1)
+		if err != nil {
+			t.Fatalf("failed to verify archive: %v", err)
+		}
+		runtime.ReadMemStats(&after)
+		if len(report.BadFrames) != 1 || !errors.Is(report.BadFrames[0], ErrFrameSizeMismatch) {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16*1024*1024 {
+			t.Fatalf("verify allocated %d bytes for a frame of 10 bytes", allocated)
+		}
+	})
+
+	t.Run("reader options", func(t *testing.T) {
+		archive := compressTestData(t, generateTestData(4*4096, 53), 4096)
+		for _, tc := range []struct {
+			option ReaderOption
+			err    error
+			bad    int
+		}{
+			{WithMaxFrameSize(1024), ErrFrameSizeLimit, 4},
+			{WithMaxCompressionRatio(1.5), ErrCompressionRatioLimit, 4},
+			{WithMaxDecompressedSize(2*4096 + 1), ErrDecompressedSizeLimit, 2},
+		} {
+			report, err := Verify(bytes.NewReader(archive), 2, tc.option)
+			if err != nil {
+				t.Fatalf("failed to verify archive: %v", err)
+			}
+			if len(report.BadFrames) != tc.bad || !errors.Is(report.BadFrames[0], tc.err) {
+				t.Fatalf("expected %d frames with %v, got %+v", tc.bad, tc.err, report.BadFrames)
+			}
+		}
+	})
+}
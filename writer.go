@@ -2,59 +2,158 @@ package szstd
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"os"
 
 	"github.com/klauspost/compress/zstd"
+	"github.com/opengs/szstd/internal/xxh64"
 	"github.com/opengs/szstd/seektable"
 )
 
-type writer struct {
+type Writer struct {
 	w io.Writer
 
+	// head seek table mode: frames are written into spool, and copied to dst after the head seek table on Close
+	dst   io.Writer
+	spool *os.File
+
 	frameSize   int
 	frameBuffer []byte
 
 	encoderBuffer []byte
 	encoder       *zstd.Encoder
 
-	seekTable seektable.Table
+	// streaming frames mode: the current frame is compressed by encoder straight into w, see streamframe.go
+	streaming    bool
+	streamOpen   bool // a frame is started
+	streamSize   int  // decompressed bytes in the current frame
+	streamOut    countingWriter
+	streamDigest *xxh64.Digest // checksum of the current frame, nil without checksums
+
+	// concurrent mode
+	encoders []*zstd.Encoder
+	frames   *pipeline[frameJob]
+	buffers  *bufferPool
+
+	seekTable *seektable.Table
+	numFrames int // frames written or successfully submitted to the pipeline
+	maxFrames int
+
+	trailers [][]byte // skippable frames written between the last data frame and the seek table
 
 	isClosed bool
 }
 
+type frameJob struct {
+	data       []byte // decompressed frame data
+	compressed []byte
+	checksum   uint32
+
+	raw              bool // frame was compressed by the caller, data is not set
+	decompressedSize uint32
+}
+
+// MaxFrameSize is the largest frame size of the writer. Seek table entries store sizes in 32 bits, and the limit
+// leaves room for the zstd overhead of incompressible data.
+const MaxFrameSize int64 = 1<<32 - 1<<24
+
 // Create new zstd writer that will automatically split input data into frames of the given size.
 // Resulting compressed data will be seekable by frame boundaries. `Close` will flush the remaning frames and write the seek table at the end.
-func NewWriter(w io.Writer, frameSize int, opts ...zstd.EOption) (io.WriteCloser, error) {
-	encoder, err := zstd.NewWriter(nil, append([]zstd.EOption{zstd.WithEncoderConcurrency(1)}, opts...)...)
+//
+// NewWriter only takes encoder options. It is the same as `NewWriterWithOptions` with `WithEncoderOptions`, which
+// should be used to configure anything else.
+func NewWriter(w io.Writer, frameSize int, opts ...zstd.EOption) (*Writer, error) {
+	return NewWriterWithOptions(w, frameSize, WithEncoderOptions(opts...))
+}
+
+// NewWriterWithOptions creates a writer as `NewWriter`, configured with writer options.
+//
+// Frame size must not exceed `MaxFrameSize`. The number of frames is limited by `seektable.MaxEntries`,
+// writes beyond it fail with `ErrTooManyFrames`.
+func NewWriterWithOptions(w io.Writer, frameSize int, opts ...WriterOption) (*Writer, error) {
+	if frameSize <= 0 {
+		return nil, errors.New("frame size must be positive")
+	}
+	if int64(frameSize) > MaxFrameSize {
+		return nil, fmt.Errorf("%w: frame size %d is larger than %d", ErrFrameTooLarge, frameSize, MaxFrameSize)
+	}
+	o, err := applyWriterOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.streamingFrames && o.concurrency > 1 {
+		return nil, errors.New("streaming frames cannot be compressed concurrently")
+	}
+
+	encoderOptions := append([]zstd.EOption{zstd.WithEncoderConcurrency(1)}, o.encoderOptions...)
+	encoder, err := zstd.NewWriter(nil, encoderOptions...)
 	if err != nil {
 		return nil, errors.Join(errors.New("failed to create zstd encoder"), err)
 	}
 
-	return &writer{
-		w:             w,
-		frameSize:     frameSize,
-		frameBuffer:   make([]byte, 0, frameSize),
-		encoder:       encoder,
-		encoderBuffer: make([]byte, 0, frameSize+frameSize/10), // allocate some extra space for compressed data
-	}, nil
+	c := &Writer{
+		w:         w,
+		frameSize: frameSize,
+		encoder:   encoder,
+		seekTable: seektable.NewTable(o.checksums),
+		maxFrames: seektable.MaxEntries(o.checksums),
+	}
+
+	if o.streamingFrames {
+		c.streaming = true
+		if o.checksums {
+			c.streamDigest = xxh64.New()
+		}
+	} else if o.concurrency > 1 {
+		c.frameBuffer = make([]byte, 0, frameSize)
+		c.encoders = make([]*zstd.Encoder, o.concurrency)
+		c.encoders[0] = encoder
+		for i := 1; i < o.concurrency; i++ {
+			if c.encoders[i], err = zstd.NewWriter(nil, encoderOptions...); err != nil {
+				for _, encoder := range c.encoders[:i] {
+					encoder.Close()
+				}
+				return nil, errors.Join(errors.New("failed to create zstd encoder"), err)
+			}
+		}
+		c.buffers = newBufferPool(o.concurrency*6, frameSize+frameSize/10) // data and compressed buffers of every frame in flight
+		c.frames = newPipeline(o.concurrency, c.compressJob, c.writeJob)
+	} else {
+		c.frameBuffer = make([]byte, 0, frameSize)
+		c.encoderBuffer = make([]byte, 0, frameSize+frameSize/10) // allocate some extra space for compressed data
+	}
+
+	if o.headTable {
+		if c.spool, err = os.CreateTemp(o.headTempDir, "szstd-*"); err != nil {
+			if c.frames != nil {
+				c.frames.Close()
+			}
+			c.closeEncoders()
+			return nil, errors.Join(errors.New("failed to create temporary file for frames"), err)
+		}
+		c.dst, c.w = w, c.spool
+		c.maxFrames-- // first entry is the head seek table
+	}
+
+	return c, nil
 }
 
-func (c *writer) Write(data []byte) (n int, err error) {
+func (c *Writer) Write(data []byte) (n int, err error) {
+	if c.streaming {
+		return c.writeStreaming(data)
+	}
 	for len(data) > 0 {
 		// fast path: if we have no data buffered and the incoming data is larger than a frame, encode directly
 		if len(c.frameBuffer) == 0 && len(data) >= c.frameSize {
 			toEncode := data[:c.frameSize]
 			data = data[c.frameSize:]
-			c.encoderBuffer = c.encoder.EncodeAll(toEncode, c.encoderBuffer[:0])
-			written, err := c.w.Write(c.encoderBuffer)
+			written, err := c.writeFrame(toEncode)
 			if err != nil {
 				return n + written, errors.Join(errors.New("error while writing frame"), err)
 			}
 			n += written
-			c.seekTable.AppendEntry(seektable.TableEntry{
-				DecompressedSize: uint32(len(toEncode)),
-				CompressedSize:   uint32(len(c.encoderBuffer)),
-			})
 			continue
 		}
 
@@ -66,15 +165,10 @@ func (c *writer) Write(data []byte) (n int, err error) {
 		n += toWrite
 
 		if len(c.frameBuffer) == int(c.frameSize) {
-			c.encoderBuffer = c.encoder.EncodeAll(c.frameBuffer, c.encoderBuffer[:0])
-			written, err := c.w.Write(c.encoderBuffer)
+			written, err := c.writeFrame(c.frameBuffer)
 			if err != nil {
 				return n - toWrite + written, errors.Join(errors.New("error while writing frame"), err)
 			}
-			c.seekTable.AppendEntry(seektable.TableEntry{
-				DecompressedSize: uint32(len(c.frameBuffer)),
-				CompressedSize:   uint32(len(c.encoderBuffer)),
-			})
 			c.frameBuffer = c.frameBuffer[:0]
 		}
 	}
@@ -82,32 +176,228 @@ func (c *writer) Write(data []byte) (n int, err error) {
 	return n, nil
 }
 
-func (c *writer) Close() error {
+// Flush compresses all buffered data into a frame and writes it, so the next `Write` starts a new frame.
+// Does nothing if there is no buffered data.
+func (c *Writer) Flush() error {
+	if c.isClosed {
+		return errors.New("writer is closed")
+	}
+	if c.streaming {
+		if err := c.endStreamFrame(); err != nil {
+			return errors.Join(errors.New("error while writing frame"), err)
+		}
+		return nil
+	}
+	if len(c.frameBuffer) == 0 {
+		return nil
+	}
+
+	if _, err := c.writeFrame(c.frameBuffer); err != nil {
+		return errors.Join(errors.New("error while writing frame"), err)
+	}
+	c.frameBuffer = c.frameBuffer[:0]
+	return nil
+}
+
+// WriteRawFrame writes an already compressed frame as is and records it in the seek table. Buffered data is
+// flushed into its own frame first. The frame must be a single complete zstd frame with entry.DecompressedSize
+// bytes of data; entry.Checksum is stored only if the writer stores checksums.
+func (c *Writer) WriteRawFrame(frame []byte, entry seektable.TableEntry) error {
+	if int64(len(frame)) != int64(entry.CompressedSize) {
+		return fmt.Errorf("frame has %d bytes, but entry has compressed size %d", len(frame), entry.CompressedSize)
+	}
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	if err := c.checkFrameLimit(); err != nil {
+		return err
+	}
+	if !c.seekTable.HasChecksums() {
+		entry.Checksum = 0
+	}
+
+	if c.frames != nil {
+		job := frameJob{
+			compressed:       append(c.buffers.Get(), frame...),
+			checksum:         entry.Checksum,
+			raw:              true,
+			decompressedSize: entry.DecompressedSize,
+		}
+		if err := c.frames.Submit(job); err != nil {
+			return errors.Join(errors.New("error while writing frame"), err)
+		}
+		c.numFrames++
+		return nil
+	}
+
+	if _, err := c.w.Write(frame); err != nil {
+		return errors.Join(errors.New("error while writing frame"), err)
+	}
+	c.seekTable.AppendEntry(entry)
+	c.numFrames++
+	return nil
+}
+
+func (c *Writer) Close() error {
 	if c.isClosed {
 		return nil
 	}
 	c.isClosed = true
+	defer c.closeEncoders()
+	defer c.removeSpool()
 
 	// Write any remaining buffered data
-	if len(c.frameBuffer) > 0 {
-		c.encoderBuffer = c.encoder.EncodeAll(c.frameBuffer, c.encoderBuffer[:0])
-		_, err := c.w.Write(c.encoderBuffer)
+	if c.streaming {
+		if err := c.endStreamFrame(); err != nil {
+			return errors.Join(errors.New("error while writing final frame"), err)
+		}
+	} else if len(c.frameBuffer) > 0 {
+		_, err := c.writeFrame(c.frameBuffer)
+		if c.frames != nil {
+			err = errors.Join(err, c.frames.Close())
+		}
 		if err != nil {
 			return errors.Join(errors.New("error while writing final frame"), err)
 		}
-		c.seekTable.AppendEntry(seektable.TableEntry{
-			DecompressedSize: uint32(len(c.frameBuffer)),
-			CompressedSize:   uint32(len(c.encoderBuffer)),
-		})
 		c.frameBuffer = c.frameBuffer[:0]
+	} else if c.frames != nil {
+		if err := c.frames.Close(); err != nil {
+			return errors.Join(errors.New("error while writing frame"), err)
+		}
+	}
+
+	if c.spool != nil {
+		if err := c.writeHead(); err != nil {
+			return errors.Join(errors.New("error while writing head seek table"), err)
+		}
+	}
+
+	// Write skippable frames that must precede the seek table
+	for _, trailer := range c.trailers {
+		if _, err := c.w.Write(trailer); err != nil {
+			return errors.Join(errors.New("error while writing trailing skippable frame"), err)
+		}
 	}
 
 	// Write seek table
-	if _, err := seektable.WriteTableToWriter(&c.seekTable, c.w); err != nil {
+	if _, err := seektable.WriteTableToWriter(c.seekTable, c.w); err != nil {
 		return errors.Join(errors.New("error while writing seek table"), err)
 	}
 
+	return nil
+}
+
+// writeHead writes the head seek table followed by the spooled frames to the destination, and switches the
+// writer to the destination with the seek table including the head entry.
+func (c *Writer) writeHead() error {
+	table := seektable.NewTable(c.seekTable.HasChecksums())
+	table.AppendEntry(seektable.TableEntry{})
+	for _, entry := range c.seekTable.Entries() {
+		table.AppendEntry(entry)
+	}
+	head := seektable.TableEntry{CompressedSize: uint32(table.Size())}
+	if table.HasChecksums() {
+		head.Checksum = seektable.Checksum(nil)
+	}
+	table.SetEntry(0, head)
+
+	if _, err := seektable.WriteTableToWriter(table, c.dst); err != nil {
+		return err
+	}
+	if _, err := c.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(c.dst, c.spool); err != nil {
+		return errors.Join(errors.New("failed to copy frames from temporary file"), err)
+	}
+	c.w, c.seekTable = c.dst, table
+	return nil
+}
+
+func (c *Writer) removeSpool() {
+	if c.spool != nil {
+		c.spool.Close()
+		os.Remove(c.spool.Name())
+	}
+}
+
+func (c *Writer) closeEncoders() {
 	c.encoder.Close()
+	for _, encoder := range c.encoders[min(1, len(c.encoders)):] {
+		encoder.Close()
+	}
+}
+
+// writeFrame compresses data as a single frame, writes it and records it in the seek table.
+// Returns the number of decompressed bytes consumed from data.
+// In concurrent mode the data is copied and the frame is written asynchronously.
+func (c *Writer) writeFrame(data []byte) (int, error) {
+	if err := c.checkFrameLimit(); err != nil {
+		return 0, err
+	}
+	if c.frames != nil {
+		job := frameJob{data: append(c.buffers.Get(), data...)}
+		if err := c.frames.Submit(job); err != nil {
+			return 0, err
+		}
+		c.numFrames++
+		return len(data), nil
+	}
+
+	c.encoderBuffer = c.encoder.EncodeAll(data, c.encoderBuffer[:0])
+	if int64(len(c.encoderBuffer)) > math.MaxUint32 {
+		return 0, fmt.Errorf("%w: compressed frame has %d bytes", ErrFrameTooLarge, len(c.encoderBuffer))
+	}
+	if _, err := c.w.Write(c.encoderBuffer); err != nil {
+		return 0, err
+	}
+	entry := seektable.TableEntry{
+		DecompressedSize: uint32(len(data)),
+		CompressedSize:   uint32(len(c.encoderBuffer)),
+	}
+	if c.seekTable.HasChecksums() {
+		entry.Checksum = seektable.Checksum(data)
+	}
+	c.seekTable.AppendEntry(entry)
+	c.numFrames++
+	return len(data), nil
+}
 
+// checkFrameLimit fails if the seek table cannot hold another frame. Frames are counted once they are written.
+func (c *Writer) checkFrameLimit() error {
+	if c.numFrames >= c.maxFrames {
+		return fmt.Errorf("%w: at most %d frames", ErrTooManyFrames, c.maxFrames)
+	}
+	return nil
+}
+
+func (c *Writer) compressJob(worker int, job *frameJob) {
+	if job.raw {
+		return
+	}
+	job.compressed = c.encoders[worker].EncodeAll(job.data, c.buffers.Get())
+	if c.seekTable.HasChecksums() {
+		job.checksum = seektable.Checksum(job.data)
+	}
+}
+
+func (c *Writer) writeJob(job *frameJob) error {
+	if !job.raw {
+		defer c.buffers.Put(job.data)
+		job.decompressedSize = uint32(len(job.data))
+	}
+	defer c.buffers.Put(job.compressed)
+
+	if int64(len(job.compressed)) > math.MaxUint32 {
+		return fmt.Errorf("%w: compressed frame has %d bytes", ErrFrameTooLarge, len(job.compressed))
+	}
+	if _, err := c.w.Write(job.compressed); err != nil {
+		return err
+	}
+	c.seekTable.AppendEntry(seektable.TableEntry{
+		DecompressedSize: job.decompressedSize,
+		CompressedSize:   uint32(len(job.compressed)),
+		Checksum:         job.checksum,
+	})
 	return nil
 }
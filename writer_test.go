@@ -0,0 +1,254 @@
+package szstd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/opengs/szstd/seektable"
+)
+
+func TestWriterConcurrency(t *testing.T) {
+	data := generateTestData(1024*1024+17, 5)
+	sequential := compressTestData(t, data, 64*1024)
+
+	for _, concurrency := range []int{0, 2, 7} {
+		compressed := bytes.NewBuffer(nil)
+		writer, err := NewWriterWithOptions(compressed, 64*1024, WithWriterConcurrency(concurrency))
+		if err != nil {
+			t.Fatalf("failed to create szstd writer: %v", err)
+		}
+		for chunk := range slices.Chunk(data, 10_000) { // mix buffered and direct frames
+			if _, err := writer.Write(chunk); err != nil {
+				t.Fatalf("failed to write data to szstd writer: %v", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("failed to close szstd writer: %v", err)
+		}
+
+		if !bytes.Equal(compressed.Bytes(), sequential) {
+			t.Fatalf("output with concurrency %d differs from sequential output", concurrency)
+		}
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+	compressed := bytes.NewBuffer(nil)
+	writer, err := NewWriterWithOptions(compressed, 1024, WithWriterConcurrency(3))
+	if err != nil {
+		t.Fatalf("failed to create szstd writer: %v", err)
+	}
+	for _, size := range []int{10, 0, 2000, 5} {
+		if _, err := writer.Write(generateTestData(size, uint64(size))); err != nil {
+			t.Fatalf("failed to write data to szstd writer: %v", err)
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("failed to flush szstd writer: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close szstd writer: %v", err)
+	}
+
+	expected := []uint32{10, 1024, 976, 5}
+	table := writer.seekTable
+	if table.NumEntries() != len(expected) {
+		t.Fatalf("expected %d frames, got %d", len(expected), table.NumEntries())
+	}
+	for i, size := range expected {
+		if entry := table.GetEntry(i); entry.DecompressedSize != size {
+			t.Fatalf("frame %d has %d bytes, expected %d", i, entry.DecompressedSize, size)
+		}
+	}
+}
+
+func TestWriterChecksums(t *testing.T) {
+	const frameSize = 4096
+	data := generateTestData(10*frameSize+5, 9)
+
+	for _, concurrency := range []int{1, 3} {
+		compressed := compressTestData(t, data, frameSize, WithChecksums(true), WithWriterConcurrency(concurrency))
+		table, err := seektable.ReadTableFromReadSeeker(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("failed to read seek table: %v", err)
+		}
+		if !table.HasChecksums() {
+			t.Fatalf("seek table must have checksums")
+		}
+		for i := 0; i < table.NumEntries(); i++ {
+			offsets := table.OffsetsByIndex(i)
+			entry := table.GetEntry(i)
+			frame := data[offsets.EntryOffsetInDecompressed : offsets.EntryOffsetInDecompressed+uint64(entry.DecompressedSize)]
+			if entry.Checksum != seektable.Checksum(frame) {
+				t.Fatalf("frame %d checksum %08x does not match data checksum %08x", i, entry.Checksum, seektable.Checksum(frame))
+			}
+		}
+	}
+}
+
+func TestWriterLimits(t *testing.T) {
+	if strconv.IntSize == 64 {
+		if _, err := NewWriter(io.Discard, int(MaxFrameSize+1)); !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("expected frame too large error, got %v", err)
+		}
+	}
+	if seektable.MaxEntries(false) != 536870910 || seektable.MaxEntries(true) != 357913940 {
+		t.Fatalf("unexpected limits of entries %d and %d", seektable.MaxEntries(false), seektable.MaxEntries(true))
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			compressed := bytes.NewBuffer(nil)
+			writer, err := NewWriterWithOptions(compressed, 100, WithWriterConcurrency(concurrency))
+			if err != nil {
+				t.Fatalf("failed to create szstd writer: %v", err)
+			}
+			writer.maxFrames = 3 // pretend the seek table is almost full
+
+			if _, err := writer.Write(make([]byte, 250)); err != nil {
+				t.Fatalf("failed to write: %v", err)
+			}
+			if _, err := writer.Write(make([]byte, 150)); !errors.Is(err, ErrTooManyFrames) {
+				t.Fatalf("expected too many frames error, got %v", err)
+			}
+			if err := writer.WriteRawFrame([]byte{1}, seektable.TableEntry{CompressedSize: 1}); !errors.Is(err, ErrTooManyFrames) {
+				t.Fatalf("expected too many frames error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("failed write", func(t *testing.T) {
+		// A frame that failed to be written does not count against the limit
+		out := &failingWriter{failures: 1}
+		writer, err := NewWriter(out, 100)
+		if err != nil {
+			t.Fatalf("failed to create szstd writer: %v", err)
+		}
+		writer.maxFrames = 1
+		if _, err := writer.Write(make([]byte, 50)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if err := writer.Flush(); err == nil {
+			t.Fatalf("flush succeeded despite write error")
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("failed to flush after write error: %v", err)
+		}
+		if writer.seekTable.NumEntries() != 1 {
+			t.Fatalf("expected 1 frame, got %d", writer.seekTable.NumEntries())
+		}
+	})
+}
+
+// failingWriter fails the first writes and then discards data.
+type failingWriter struct {
+	failures int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.failures > 0 {
+		w.failures--
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+func TestWriterStreamingFrames(t *testing.T) {
+	data := generateTestData(1000*1000+17, 51)
+	for _, checksums := range []bool{false, true} {
+		for _, head := range []bool{false, true} {
+			t.Run(fmt.Sprintf("checksums=%v/head=%v", checksums, head), func(t *testing.T) {
+				opts := []WriterOption{WithStreamingFrames(true), WithChecksums(checksums)}
+				if head {
+					opts = append(opts, WithHeadSeekTable(t.TempDir()))
+				}
+				compressed := bytes.NewBuffer(nil)
+				writer, err := NewWriterWithOptions(compressed, 300*1000, opts...)
+				if err != nil {
+					t.Fatalf("failed to create writer: %v", err)
+				}
+				for chunk := range slices.Chunk(data[:500*1000], 7777) {
+					if _, err := writer.Write(chunk); err != nil {
+						t.Fatalf("failed to write: %v", err)
+					}
+				}
+				if err := writer.Flush(); err != nil {
+					t.Fatalf("failed to flush: %v", err)
+				}
+				if _, err := writer.Write(data[500*1000:]); err != nil {
+					t.Fatalf("failed to write: %v", err)
+				}
+				if err := writer.Close(); err != nil {
+					t.Fatalf("failed to close writer: %v", err)
+				}
+
+				reader, err := NewReadSeeker(bytes.NewReader(compressed.Bytes()))
+				if err != nil {
+					t.Fatalf("failed to create reader: %v", err)
+				}
+				defer reader.Close()
+				var sizes []uint32
+				for _, frame := range reader.Frames() {
+					sizes = append(sizes, frame.DecompressedSize)
+				}
+				expected := []uint32{300 * 1000, 200 * 1000, 300 * 1000, 200*1000 + 17}
+				if head {
+					expected = slices.Insert(expected, 0, 0)
+				}
+				if !slices.Equal(sizes, expected) {
+					t.Fatalf("expected frame sizes %v, got %v", expected, sizes)
+				}
+				decompressed, err := io.ReadAll(reader)
+				if err != nil || !bytes.Equal(decompressed, data) {
+					t.Fatalf("failed to read data: %v", err)
+				}
+				if report, err := Verify(bytes.NewReader(compressed.Bytes()), 0); err != nil || !report.OK() || report.ChecksumsChecked != checksums {
+					t.Fatalf("verification failed: %+v %v", report, err)
+				}
+			})
+		}
+	}
+
+	if _, err := NewWriterWithOptions(io.Discard, 1000, WithStreamingFrames(true), WithWriterConcurrency(2)); err == nil {
+		t.Fatalf("expected error for concurrent streaming frames")
+	}
+}
+
+func TestWriterStreamingFramesMemory(t *testing.T) {
+	const frameSize = 256 * 1024 * 1024
+	data := generateTestData(8*1024*1024, 52)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	buffer := bytes.NewBuffer(make([]byte, 0, len(data)))
+	writer, err := NewWriterWithOptions(buffer, frameSize, WithStreamingFrames(true), WithChecksums(true))
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+	for chunk := range slices.Chunk(data, 64*1024) {
+		if _, err := writer.Write(chunk); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > frameSize/4 {
+		t.Fatalf("writer allocated %d bytes for frames of %d bytes", allocated, frameSize)
+	}
+	reader, err := NewReadSeeker(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+	if decompressed, err := io.ReadAll(reader); err != nil || !bytes.Equal(decompressed, data) || reader.NumFrames() != 1 {
+		t.Fatalf("failed to read data of %d frames: %v", reader.NumFrames(), err)
+	}
+}